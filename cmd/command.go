@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"go-server/internal/registry"
+)
+
+const usage = `Usage:
+  server                          run the HTTP server
+  server dlq list [limit] [offset]
+  server dlq inspect <id>
+  server dlq redrive <id>
+  server dlq discard <id>
+  server rebuild                  rebuild the Redis rankings from MongoDB
+  server recompute [-weights file] [-from time] [-to time] [-exclude-flagged]
+                                  recompute the scores from the interactions log and report the diff
+  server recompute swap           replace the scores with the recomputed ones and rebuild the rankings
+  server related                  compute the related videos of every video`
+
+// runCommand runs an administrative subcommand instead of the HTTP server
+func runCommand(rg registry.Interactor, args []string) {
+	switch args[0] {
+	case "dlq":
+		runDeadLetterCommand(rg, args[1:])
+	case "rebuild":
+		runRebuildCommand(rg)
+	case "recompute":
+		runRecomputeCommand(rg, args[1:])
+	case "related":
+		runRelatedCommand(rg)
+	default:
+		exitWithUsage()
+	}
+}
+
+// runRebuildCommand rebuilds the Redis rankings, logging progress, and prints the final status
+func runRebuildCommand(rg registry.Interactor) {
+	status, err := rg.NewRebuildService().Rebuild(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to rebuild rankings: %v", err)
+	}
+	printJSON(status)
+}
+
+// runRelatedCommand computes the related videos now and prints the report
+func runRelatedCommand(rg registry.Interactor) {
+	report, err := rg.NewRelatedService().ComputeRelated(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to compute related videos: %v", err)
+	}
+	printJSON(report)
+}
+
+func exitWithUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Fatalf("Failed to print result: %v", err)
+	}
+}
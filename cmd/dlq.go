@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"go-server/internal/registry"
+)
+
+// runDeadLetterCommand lists, inspects, re-drives or discards dead letters
+func runDeadLetterCommand(rg registry.Interactor, args []string) {
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	ctx := context.Background()
+	service := rg.NewDeadLetterService()
+
+	switch args[0] {
+	case "list":
+		limit, offset := 50, 0
+		var err error
+		if len(args) > 1 {
+			if limit, err = strconv.Atoi(args[1]); err != nil {
+				exitWithUsage()
+			}
+		}
+		if len(args) > 2 {
+			if offset, err = strconv.Atoi(args[2]); err != nil {
+				exitWithUsage()
+			}
+		}
+		letters, err := service.ListDeadLetters(ctx, limit, offset)
+		if err != nil {
+			log.Fatalf("Failed to list dead letters: %v", err)
+		}
+		printJSON(letters)
+	case "inspect":
+		if len(args) < 2 {
+			exitWithUsage()
+		}
+		letter, err := service.GetDeadLetter(ctx, args[1])
+		if err != nil {
+			log.Fatalf("Failed to get dead letter %s: %v", args[1], err)
+		}
+		printJSON(letter)
+	case "redrive":
+		if len(args) < 2 {
+			exitWithUsage()
+		}
+		if err := service.RedriveDeadLetter(ctx, args[1]); err != nil {
+			log.Fatalf("Failed to re-drive dead letter %s: %v", args[1], err)
+		}
+		fmt.Printf("Dead letter %s re-driven\n", args[1])
+	case "discard":
+		if len(args) < 2 {
+			exitWithUsage()
+		}
+		if err := service.DiscardDeadLetter(ctx, args[1]); err != nil {
+			log.Fatalf("Failed to discard dead letter %s: %v", args[1], err)
+		}
+		fmt.Printf("Dead letter %s discarded\n", args[1])
+	default:
+		exitWithUsage()
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+
 	"go-server/config"
 	"go-server/internal/infrastructure/router"
 	"go-server/internal/registry"
@@ -13,7 +17,24 @@ func main() {
 	mongoDB := mongo.NewMongo(config.C.MongoDB.URLString, config.C.MongoDB.DatabaseName)
 	redisClient := redis.InitRedis(config.C.Redis.Host + ":" + config.C.Redis.Port)
 
-	rg := registry.NewInteractor(mongoDB, redisClient)
+	rg := registry.NewInteractor(mongoDB, redisClient, config.C)
+
+	if len(os.Args) > 1 {
+		runCommand(rg, os.Args[1:])
+		return
+	}
+
+	if err := rg.NewInteractionService().EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to prepare the interactions collection: %v", err)
+	}
+	if err := rg.NewVideoService().EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to prepare the video catalog: %v", err)
+	}
+	go rg.NewOutboxRelayService().StartRelay(context.Background())
+	go rg.NewWeightService().StartReloader(context.Background())
+	go rg.NewRebuildService().RebuildOnStartup(context.Background())
+	go rg.NewReconcileService().StartReconciler(context.Background())
+	go rg.NewRelatedService().StartScheduler(context.Background())
 
 	masterHandler := rg.NewAppHandler()
 	router.Initialize(masterHandler)
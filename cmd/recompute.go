@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/registry"
+)
+
+// runRecomputeCommand recomputes the scores from the interactions log and prints the diff report,
+// or swaps previously recomputed scores in
+func runRecomputeCommand(rg registry.Interactor, args []string) {
+	ctx := context.Background()
+	service := rg.NewRecomputeService()
+
+	if len(args) > 0 && args[0] == "swap" {
+		if err := service.Swap(ctx); err != nil {
+			log.Fatalf("Failed to swap recomputed scores: %v", err)
+		}
+		fmt.Println("Recomputed scores swapped in and rankings rebuilt")
+		return
+	}
+
+	req := &entity.RecomputeReq{}
+	flags := flag.NewFlagSet("recompute", flag.ExitOnError)
+	flags.Usage = exitWithUsage
+	weightsFile := flags.String("weights", "", "JSON weight table to recompute with instead of the active one")
+	flags.Func("from", "replay interactions created at or after this RFC 3339 time", timeFlag(&req.From))
+	flags.Func("to", "replay interactions created before this RFC 3339 time", timeFlag(&req.To))
+	flags.BoolVar(&req.ExcludeFlagged, "exclude-flagged", false, "skip the interactions of flagged users")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		exitWithUsage()
+	}
+
+	weights := rg.NewWeightService()
+	if *weightsFile != "" {
+		var err error
+		if weights, err = rg.LoadWeightTable(*weightsFile); err != nil {
+			log.Fatalf("Failed to load weights from %s: %v", *weightsFile, err)
+		}
+	}
+
+	report, err := service.Recompute(ctx, weights, req)
+	if err != nil {
+		log.Fatalf("Failed to recompute scores: %v", err)
+	}
+	printJSON(report)
+}
+
+// timeFlag parses an RFC 3339 flag value into dest
+func timeFlag(dest **time.Time) func(string) error {
+	return func(value string) error {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		*dest = &t
+		return nil
+	}
+}
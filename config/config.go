@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"log"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
@@ -11,6 +13,25 @@ type (
 	Config struct {
 		MongoDB
 		Redis
+		EventBus
+		Stream
+		Kafka
+		Outbox
+		Idempotency
+		Weights
+		Hot
+		Window
+		Trending
+		Rules
+		Fraud
+		Rebuild
+		Recompute
+		Reconcile
+		Personal
+		Blend
+		Related
+		Video
+		Segment
 	}
 	MongoDB struct {
 		DatabaseName string `env:"MONGO_DATABASE_NAME"`
@@ -22,6 +43,150 @@ type (
 		Port     string `env:"REDIS_PORT"`
 		Password string `env:"REDIS_PASSWORD"`
 	}
+
+	// EventBus selects the transport for interaction events: redis, memory or kafka
+	EventBus struct {
+		Driver     string        `env:"EVENT_BUS_DRIVER" env-default:"redis"`
+		BufferSize int           `env:"EVENT_BUS_BUFFER_SIZE" env-default:"1024"`
+		MaxRetries int           `env:"EVENT_BUS_MAX_RETRIES" env-default:"5"`
+		Backoff    time.Duration `env:"EVENT_BUS_BACKOFF" env-default:"200ms"`
+	}
+
+	// Stream configures the Redis Stream used to carry interaction events
+	Stream struct {
+		Name          string        `env:"STREAM_NAME" env-default:"interaction_events"`
+		Group         string        `env:"STREAM_GROUP" env-default:"score_service"`
+		Consumer      string        `env:"STREAM_CONSUMER"`
+		MaxLen        int64         `env:"STREAM_MAX_LEN" env-default:"100000"`
+		BatchSize     int64         `env:"STREAM_BATCH_SIZE" env-default:"50"`
+		Block         time.Duration `env:"STREAM_BLOCK" env-default:"5s"`
+		ClaimMinIdle  time.Duration `env:"STREAM_CLAIM_MIN_IDLE" env-default:"1m"`
+		ClaimInterval time.Duration `env:"STREAM_CLAIM_INTERVAL" env-default:"30s"`
+		MaxDeliveries int64         `env:"STREAM_MAX_DELIVERIES" env-default:"5"`
+	}
+
+	Kafka struct {
+		Brokers []string `env:"KAFKA_BROKERS" env-separator:"," env-default:"localhost:9092"`
+		Topic   string   `env:"KAFKA_TOPIC" env-default:"interaction_events"`
+		GroupID string   `env:"KAFKA_GROUP_ID" env-default:"score_service"`
+	}
+
+	// Outbox configures the relay publishing stored interaction events
+	Outbox struct {
+		BatchSize     int           `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+		PollInterval  time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"500ms"`
+		Lease         time.Duration `env:"OUTBOX_LEASE" env-default:"30s"`
+		BaseBackoff   time.Duration `env:"OUTBOX_BASE_BACKOFF" env-default:"1s"`
+		MaxBackoff    time.Duration `env:"OUTBOX_MAX_BACKOFF" env-default:"5m"`
+		SentRetention time.Duration `env:"OUTBOX_SENT_RETENTION" env-default:"24h"`
+	}
+
+	// Idempotency configures how long idempotency keys and processed event IDs are remembered
+	Idempotency struct {
+		TTL               time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h"`
+		LockTTL           time.Duration `env:"IDEMPOTENCY_LOCK_TTL" env-default:"30s"`
+		ProcessedEventTTL time.Duration `env:"PROCESSED_EVENT_TTL" env-default:"168h"`
+	}
+
+	// Weights configures the interaction weight table: a JSON file takes precedence over the inline list
+	Weights struct {
+		File           string        `env:"WEIGHTS_FILE"`
+		Inline         string        `env:"INTERACTION_WEIGHTS"`
+		ReloadInterval time.Duration `env:"WEIGHTS_RELOAD_INTERVAL" env-default:"30s"`
+	}
+
+	// Hot configures the time-decayed ranking
+	Hot struct {
+		HalfLife       time.Duration `env:"HOT_HALF_LIFE" env-default:"24h"`
+		RebaseInterval time.Duration `env:"HOT_REBASE_INTERVAL" env-default:"1h"`
+		RebaseAfter    time.Duration `env:"HOT_REBASE_AFTER" env-default:"168h"`
+		MinScore       float64       `env:"HOT_MIN_SCORE" env-default:"0.001"`
+	}
+
+	// Window configures the hourly and daily leaderboard buckets
+	Window struct {
+		HourBucketTTL time.Duration `env:"WINDOW_HOUR_BUCKET_TTL" env-default:"48h"`
+		DayBucketTTL  time.Duration `env:"WINDOW_DAY_BUCKET_TTL" env-default:"192h"`
+		CacheTTL      time.Duration `env:"WINDOW_CACHE_TTL" env-default:"30s"`
+	}
+
+	// Trending configures the velocity ranking, the hour bucket TTL must cover the baseline
+	Trending struct {
+		BaselineHours int     `env:"TRENDING_BASELINE_HOURS" env-default:"24"`
+		Smoothing     float64 `env:"TRENDING_SMOOTHING" env-default:"1"`
+	}
+
+	// Rules configures the per user and video anti-spam rules, the built-in defaults apply without a file
+	Rules struct {
+		File string `env:"RULES_FILE"`
+	}
+
+	// Fraud configures the fraud detectors, a zero limit disables a detector
+	Fraud struct {
+		BurstLimit          int64         `env:"FRAUD_BURST_LIMIT" env-default:"120"`
+		BurstWindow         time.Duration `env:"FRAUD_BURST_WINDOW" env-default:"1m"`
+		FingerprintMaxUsers int64         `env:"FRAUD_FINGERPRINT_MAX_USERS" env-default:"20"`
+		FingerprintWindow   time.Duration `env:"FRAUD_FINGERPRINT_WINDOW" env-default:"1h"`
+		RequireView         bool          `env:"FRAUD_REQUIRE_VIEW" env-default:"false"`
+	}
+
+	// Rebuild configures rebuilding the Redis rankings from MongoDB: never, if_empty or always at startup
+	Rebuild struct {
+		OnStartup string        `env:"REBUILD_ON_STARTUP" env-default:"if_empty"`
+		BatchSize int           `env:"REBUILD_BATCH_SIZE" env-default:"1000"`
+		Lease     time.Duration `env:"REBUILD_LEASE" env-default:"1m"`
+	}
+
+	// Recompute configures recomputing the scores from the interactions log
+	Recompute struct {
+		BatchSize int `env:"RECOMPUTE_BATCH_SIZE" env-default:"1000"`
+		ReportTop int `env:"RECOMPUTE_REPORT_TOP" env-default:"20"`
+	}
+
+	// Reconcile configures the background comparison of the video scores with the global ranking
+	Reconcile struct {
+		Interval     time.Duration `env:"RECONCILE_INTERVAL" env-default:"5m"`
+		BatchSize    int           `env:"RECONCILE_BATCH_SIZE" env-default:"500"`
+		ConfirmDelay time.Duration `env:"RECONCILE_CONFIRM_DELAY" env-default:"2s"`
+		Tolerance    float64       `env:"RECONCILE_TOLERANCE" env-default:"0.000001"`
+	}
+
+	// Personal bounds the personal ranking ZSETs, 0 disabling either bound
+	Personal struct {
+		MaxSize int64         `env:"PERSONAL_RANKING_MAX_SIZE" env-default:"500"`
+		IdleTTL time.Duration `env:"PERSONAL_RANKING_IDLE_TTL" env-default:"168h"`
+	}
+
+	// Blend configures the blended personal ranking, each weight applies to a ranking normalized to its top score
+	Blend struct {
+		PersonalWeight float64 `env:"BLEND_PERSONAL_WEIGHT" env-default:"0.5"`
+		GlobalWeight   float64 `env:"BLEND_GLOBAL_WEIGHT" env-default:"0.3"`
+		TrendingWeight float64 `env:"BLEND_TRENDING_WEIGHT" env-default:"0.2"`
+		Candidates     int     `env:"BLEND_CANDIDATES" env-default:"200"`
+	}
+
+	// Related configures the item-to-item related videos job and recommendations
+	Related struct {
+		Interval         time.Duration `env:"RELATED_INTERVAL" env-default:"24h"`
+		TTL              time.Duration `env:"RELATED_TTL" env-default:"72h"`
+		BatchSize        int           `env:"RELATED_BATCH_SIZE" env-default:"1000"`
+		TopK             int           `env:"RELATED_TOP_K" env-default:"20"`
+		MinSupport       int           `env:"RELATED_MIN_SUPPORT" env-default:"2"`
+		MaxVideosPerUser int           `env:"RELATED_MAX_VIDEOS_PER_USER" env-default:"50"`
+		MaxPairsPerVideo int           `env:"RELATED_MAX_PAIRS_PER_VIDEO" env-default:"1000"`
+		RecentVideos     int           `env:"RELATED_RECENT_VIDEOS" env-default:"20"`
+	}
+
+	// Video configures the video catalog, Validation is off, known or published
+	Video struct {
+		Validation string `env:"VIDEO_VALIDATION" env-default:"off"`
+	}
+
+	// Segment configures the segment rankings, Dimensions lists category, creator, language and region
+	Segment struct {
+		Dimensions []string      `env:"SEGMENT_DIMENSIONS" env-separator:"," env-default:"category,creator,language,region"`
+		CacheTTL   time.Duration `env:"SEGMENT_CACHE_TTL" env-default:"30s"`
+	}
 )
 
 var C Config
@@ -39,6 +204,19 @@ func LoadConfig() {
 		log.Fatalln("Application config parsing failed: " + err.Error() + " => Exit!")
 		return
 	}
+	if err := cfg.validate(); err != nil {
+		log.Fatalln("Application config validation failed: " + err.Error() + " => Exit!")
+		return
+	}
 
 	log.Println("Load Config Successfully!")
 }
+
+// validate rejects values the services cannot work with
+func (c *Config) validate() error {
+	if c.Hot.HalfLife <= 0 {
+		// Hot scores grow by 2^(age/half-life), which is not a number without a positive half-life
+		return errors.New("HOT_HALF_LIFE must be positive")
+	}
+	return nil
+}
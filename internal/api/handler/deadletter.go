@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+
+	"go-server/internal/usecase/deadletter"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterHandler interface {
+	ListDeadLetters(c *gin.Context)
+	GetDeadLetter(c *gin.Context)
+	RedriveDeadLetter(c *gin.Context)
+	DiscardDeadLetter(c *gin.Context)
+}
+
+type deadLetterHandler struct {
+	DeadLetterUC deadletter.UseCase
+}
+
+func NewDeadLetterHandler(dluc deadletter.UseCase) DeadLetterHandler {
+	return &deadLetterHandler{
+		DeadLetterUC: dluc,
+	}
+}
+
+// ListDeadLetters godoc
+// @Summary List dead letters
+// @Description List interaction events the score consumer gave up on, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/dead-letters [get]
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} []entity.DeadLetter
+// @Failure 500
+// @Failure 400
+func (h *deadLetterHandler) ListDeadLetters(c *gin.Context) {
+	limit, offset, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+
+	letters, err := h.DeadLetterUC.ListDeadLetters(c, limit, offset)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, letters)
+}
+
+// GetDeadLetter godoc
+// @Summary Get dead letter
+// @Description Get a dead letter with its error, attempt count and original payload
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/dead-letters/{id} [get]
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} entity.DeadLetter
+// @Failure 500
+// @Failure 404
+func (h *deadLetterHandler) GetDeadLetter(c *gin.Context) {
+	letter, err := h.DeadLetterUC.GetDeadLetter(c, c.Param("id"))
+	if errors.Is(err, deadletter.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, letter)
+}
+
+// RedriveDeadLetter godoc
+// @Summary Re-drive dead letter
+// @Description Hand the original event of a dead letter back to the outbox relay and remove the dead letter
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/dead-letters/{id}/redrive [post]
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} string
+// @Failure 500
+// @Failure 422
+// @Failure 404
+func (h *deadLetterHandler) RedriveDeadLetter(c *gin.Context) {
+	err := h.DeadLetterUC.RedriveDeadLetter(c, c.Param("id"))
+	if errors.Is(err, deadletter.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if errors.Is(err, deadletter.ErrUndecodable) {
+		c.AbortWithStatusJSON(422, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "Dead letter re-driven successfully")
+}
+
+// DiscardDeadLetter godoc
+// @Summary Discard dead letter
+// @Description Remove a dead letter without replaying it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/dead-letters/{id} [delete]
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} string
+// @Failure 500
+// @Failure 404
+func (h *deadLetterHandler) DiscardDeadLetter(c *gin.Context) {
+	err := h.DeadLetterUC.DiscardDeadLetter(c, c.Param("id"))
+	if errors.Is(err, deadletter.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "Dead letter discarded successfully")
+}
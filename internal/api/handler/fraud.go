@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"errors"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/fraud"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FraudHandler interface {
+	ListQuarantined(c *gin.Context)
+	ReleaseQuarantined(c *gin.Context)
+	PurgeQuarantined(c *gin.Context)
+	ListFlaggedUsers(c *gin.Context)
+	FlagUser(c *gin.Context)
+	UnflagUser(c *gin.Context)
+	SubtractUserContribution(c *gin.Context)
+}
+
+type fraudHandler struct {
+	FraudUC fraud.UseCase
+}
+
+func NewFraudHandler(fuc fraud.UseCase) FraudHandler {
+	return &fraudHandler{
+		FraudUC: fuc,
+	}
+}
+
+// ListQuarantined godoc
+// @Summary List quarantined events
+// @Description List interaction events held back by fraud detection, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/quarantine [get]
+// @Param status query string false "Status: held or released (default all)"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} []entity.QuarantinedEvent
+// @Failure 500
+// @Failure 400
+func (h *fraudHandler) ListQuarantined(c *gin.Context) {
+	status := entity.QuarantineStatus(c.Query("status"))
+	switch status {
+	case "", entity.QuarantineHeld, entity.QuarantineReleased:
+	default:
+		c.AbortWithStatusJSON(400, "Invalid status")
+		return
+	}
+	limit, offset, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.FraudUC.ListQuarantined(c, status, limit, offset)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, events)
+}
+
+// ReleaseQuarantined godoc
+// @Summary Release quarantined event
+// @Description Publish a quarantined event again so the score consumer applies it without fraud screening
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/quarantine/{id}/release [post]
+// @Param id path string true "Quarantined event ID"
+// @Success 200 {object} string
+// @Failure 500
+// @Failure 404
+func (h *fraudHandler) ReleaseQuarantined(c *gin.Context) {
+	err := h.FraudUC.ReleaseQuarantined(c, c.Param("id"))
+	if errors.Is(err, fraud.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "Quarantined event released successfully")
+}
+
+// PurgeQuarantined godoc
+// @Summary Purge quarantined event
+// @Description Remove a quarantined event without applying it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/quarantine/{id} [delete]
+// @Param id path string true "Quarantined event ID"
+// @Success 200 {object} string
+// @Failure 500
+// @Failure 404
+func (h *fraudHandler) PurgeQuarantined(c *gin.Context) {
+	err := h.FraudUC.PurgeQuarantined(c, c.Param("id"))
+	if errors.Is(err, fraud.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "Quarantined event purged successfully")
+}
+
+// ListFlaggedUsers godoc
+// @Summary List flagged users
+// @Description List users suspected of fraud, most recently flagged first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/flagged-users [get]
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} []entity.FlaggedUser
+// @Failure 500
+// @Failure 400
+func (h *fraudHandler) ListFlaggedUsers(c *gin.Context) {
+	limit, offset, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+
+	users, err := h.FraudUC.ListFlaggedUsers(c, limit, offset)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, users)
+}
+
+// FlagUser godoc
+// @Summary Flag user
+// @Description Flag a user as suspicious, all of their later events are quarantined
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/flagged-users/{user_id} [put]
+// @Param user_id path string true "User ID"
+// @Param reason query string false "Reason"
+// @Success 200 {object} string
+// @Failure 500
+func (h *fraudHandler) FlagUser(c *gin.Context) {
+	if err := h.FraudUC.FlagUser(c, c.Param("user_id"), c.Query("reason")); err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "User flagged successfully")
+}
+
+// UnflagUser godoc
+// @Summary Unflag user
+// @Description Remove the flag of a user, events already in quarantine stay there
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/flagged-users/{user_id} [delete]
+// @Param user_id path string true "User ID"
+// @Success 200 {object} string
+// @Failure 500
+// @Failure 404
+func (h *fraudHandler) UnflagUser(c *gin.Context) {
+	err := h.FraudUC.UnflagUser(c, c.Param("user_id"))
+	if errors.Is(err, fraud.ErrUserNotFlagged) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "User unflagged successfully")
+}
+
+// SubtractUserContribution godoc
+// @Summary Subtract flagged user's contribution
+// @Description Remove everything a flagged user added to the video scores and the global, hot, windowed, trending and segment rankings, and reset their personal ranking
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/flagged-users/{user_id}/subtract [post]
+// @Param user_id path string true "User ID"
+// @Success 200 {object} map[string]int64
+// @Failure 500
+// @Failure 404
+func (h *fraudHandler) SubtractUserContribution(c *gin.Context) {
+	adjusted, err := h.FraudUC.SubtractUserContribution(c, c.Param("user_id"))
+	if errors.Is(err, fraud.ErrUserNotFlagged) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, gin.H{"videos_adjusted": adjusted})
+}
@@ -1,6 +1,34 @@
 package handler
 
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
 type AppHandler struct {
 	InteractionHandler
 	ScoreHandler
+	DeadLetterHandler
+	WeightHandler
+	FraudHandler
+	RebuildHandler
+	ReconcileHandler
+	RelatedHandler
+	VideoHandler
+}
+
+// parseAdminPage reads the limit and offset query parameters of admin listings, aborting with 400 if invalid
+func parseAdminPage(c *gin.Context) (int, int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.AbortWithStatusJSON(400, "Invalid limit")
+		return 0, 0, false
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.AbortWithStatusJSON(400, "Invalid offset")
+		return 0, 0, false
+	}
+	return limit, offset, true
 }
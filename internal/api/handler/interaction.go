@@ -2,6 +2,14 @@
 package handler
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go-server/internal/common/constant"
 	"go-server/internal/entity"
 	"go-server/internal/usecase/interaction"
 
@@ -11,6 +19,8 @@ import (
 // InteractionHandler interface
 type InteractionHandler interface {
 	CreateNewInteraction(c *gin.Context)
+	CreateInteractions(c *gin.Context)
+	RetractInteraction(c *gin.Context)
 }
 
 type interactionHandler struct {
@@ -34,7 +44,14 @@ func NewInteractionHandler(euc interaction.UseCase) InteractionHandler {
 // @Param user_id path string true "User ID"
 // @Param post_id path string true "Post ID"
 // @Param interaction_type path string true "Interaction Type"
+// @Param Idempotency-Key header string false "Idempotency key, replays the original response when reused"
+// @Param X-Client-Fingerprint header string false "Client device fingerprint, used by fraud detection"
+// @Param X-Client-Region header string false "Client region code such as vn or us-ca, used by the region rankings"
 // @Success 200 {object} string
+// @Failure 400
+// @Failure 409
+// @Failure 422
+// @Failure 429
 // @Failure 500
 func (h *interactionHandler) CreateNewInteraction(c *gin.Context) {
 	var req *entity.UserInteractionReq
@@ -43,11 +60,163 @@ func (h *interactionHandler) CreateNewInteraction(c *gin.Context) {
 		return
 	}
 
-	if err := h.InteractionUC.CreateNewInteraction(c, req); err != nil {
+	if req.EventID == "" {
+		req.EventID = c.GetHeader(constant.IdempotencyKeyHeader)
+	}
+	if req.Fingerprint == "" {
+		req.Fingerprint = c.GetHeader(constant.FingerprintHeader)
+	}
+	if req.Region == "" {
+		req.Region = c.GetHeader(constant.RegionHeader)
+	}
+
+	replayed, err := h.InteractionUC.CreateNewInteraction(c, req)
+	if errors.Is(err, interaction.ErrUnknownInteractionType) || errors.Is(err, interaction.ErrInvalidInteraction) {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
+	}
+	if errors.Is(err, interaction.ErrRequestInProgress) {
+		c.AbortWithStatusJSON(409, err.Error())
+		return
+	}
+	if errors.Is(err, interaction.ErrInteractionLimited) {
+		c.AbortWithStatusJSON(429, err.Error())
+		return
+	}
+	if errors.Is(err, interaction.ErrUnknownVideo) || errors.Is(err, interaction.ErrVideoUnpublished) {
+		c.AbortWithStatusJSON(422, err.Error())
+		return
+	}
+	if err != nil {
 		c.AbortWithStatusJSON(500, err)
 		return
 	}
 
+	if replayed {
+		c.Header(constant.IdempotentReplayedHeader, "true")
+	}
+
 	c.JSON(200, "Interaction created successfully")
 	return
 }
+
+// CreateInteractions godoc
+// @Summary Create interactions in bulk
+// @Description Create up to 1000 interactions in one request, sent as a JSON array or as NDJSON (one interaction per line, Content-Type application/x-ndjson). Each item is validated on its own; the response lists which items were accepted, replayed (already processed event_id) or rejected and why.
+// @Tags interaction
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Router /v1/interactions:batchCreate [post]
+// @Param request body []entity.UserInteractionReq true "Interactions"
+// @Success 200 {object} entity.BulkInteractionResult
+// @Failure 400
+// @Failure 413
+// @Failure 500
+func (h *interactionHandler) CreateInteractions(c *gin.Context) {
+	items, err := readBulkItems(c)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.AbortWithStatusJSON(413, fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
+	}
+
+	var (
+		reqs     []*entity.UserInteractionReq
+		indexes  []int
+		failures []*entity.BulkItemResult
+	)
+	for i, item := range items {
+		var req entity.UserInteractionReq
+		if err := json.Unmarshal(item, &req); err != nil {
+			failures = append(failures, &entity.BulkItemResult{Index: i, Status: entity.BulkItemRejected, Error: err.Error()})
+			continue
+		}
+		reqs = append(reqs, &req)
+		indexes = append(indexes, i)
+	}
+
+	result, err := h.InteractionUC.CreateInteractions(c, reqs)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	for _, item := range result.Items {
+		item.Index = indexes[item.Index]
+	}
+	for _, failure := range failures {
+		result.Add(failure)
+	}
+	sort.Slice(result.Items, func(i, j int) bool { return result.Items[i].Index < result.Items[j].Index })
+
+	c.JSON(200, result)
+}
+
+// RetractInteraction godoc
+// @Summary Retract an interaction
+// @Description Undo an interaction (unlike, delete comment, unshare). The interaction is marked as retracted and its score is subtracted from the rankings asynchronously, never taking a score below zero.
+// @Tags interaction
+// @Produce json
+// @Router /v1/interactions/{event_id} [delete]
+// @Param event_id path string true "Event ID of the interaction, or the idempotency key it was sent with"
+// @Param user_id query string true "User ID that made the interaction"
+// @Success 200 {object} string
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 500
+func (h *interactionHandler) RetractInteraction(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.AbortWithStatusJSON(400, "user_id is required")
+		return
+	}
+
+	err := h.InteractionUC.RetractInteraction(c, c.Param("event_id"), userID)
+	if errors.Is(err, interaction.ErrInteractionNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if errors.Is(err, interaction.ErrInteractionRetracted) {
+		c.AbortWithStatusJSON(409, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "Interaction retracted successfully")
+}
+
+// readBulkItems splits a bulk request body into raw items, from NDJSON lines or a JSON array.
+// The body is capped at MaxBulkRequestBytes, and NDJSON stops being read past MaxBulkInteractions lines.
+func readBulkItems(c *gin.Context) ([]json.RawMessage, error) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, constant.MaxBulkRequestBytes)
+	var items []json.RawMessage
+	if c.ContentType() == "application/x-ndjson" {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for len(items) <= constant.MaxBulkInteractions && scanner.Scan() {
+			if line := scanner.Bytes(); len(line) > 0 {
+				items = append(items, append(json.RawMessage(nil), line...))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if err := json.NewDecoder(body).Decode(&items); err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("no interactions in request")
+	}
+	if len(items) > constant.MaxBulkInteractions {
+		return nil, fmt.Errorf("too many interactions, at most %d are allowed", constant.MaxBulkInteractions)
+	}
+	return items, nil
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+
+	"go-server/internal/usecase/rebuild"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RebuildHandler interface {
+	StartRebuild(c *gin.Context)
+	GetRebuildStatus(c *gin.Context)
+}
+
+type rebuildHandler struct {
+	RebuildUC rebuild.UseCase
+}
+
+func NewRebuildHandler(ruc rebuild.UseCase) RebuildHandler {
+	return &rebuildHandler{
+		RebuildUC: ruc,
+	}
+}
+
+// StartRebuild godoc
+// @Summary Rebuild rankings
+// @Description Start rebuilding the global and personal Redis rankings from the MongoDB scores in the background
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/rankings/rebuild [post]
+// @Success 202 {object} entity.RebuildStatus
+// @Failure 500
+// @Failure 409
+func (h *rebuildHandler) StartRebuild(c *gin.Context) {
+	status, err := h.RebuildUC.StartRebuild(c)
+	if errors.Is(err, rebuild.ErrRebuildRunning) {
+		c.AbortWithStatusJSON(409, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(202, status)
+}
+
+// GetRebuildStatus godoc
+// @Summary Get rebuild status
+// @Description Get the progress of the running or last ranking rebuild of any instance
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/rankings/rebuild [get]
+// @Success 200 {object} entity.RebuildStatus
+// @Failure 500
+// @Failure 404
+func (h *rebuildHandler) GetRebuildStatus(c *gin.Context) {
+	status, err := h.RebuildUC.GetStatus(c)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	if status == nil {
+		c.AbortWithStatusJSON(404, "No rebuild has run")
+		return
+	}
+	c.JSON(200, status)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"go-server/internal/usecase/reconcile"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconcileHandler interface {
+	GetReconcileStats(c *gin.Context)
+}
+
+type reconcileHandler struct {
+	ReconcileUC reconcile.UseCase
+}
+
+func NewReconcileHandler(ruc reconcile.UseCase) ReconcileHandler {
+	return &reconcileHandler{
+		ReconcileUC: ruc,
+	}
+}
+
+// GetReconcileStats godoc
+// @Summary Get reconciliation drift
+// @Description Get the drift between the MongoDB video scores and the Redis global ranking found by the reconciler, summed over all passes and for the last pass
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/rankings/reconciliation [get]
+// @Success 200 {object} entity.ReconcileStats
+// @Failure 500
+func (h *reconcileHandler) GetReconcileStats(c *gin.Context) {
+	stats, err := h.ReconcileUC.GetStats(c)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, stats)
+}
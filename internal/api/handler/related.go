@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"go-server/internal/usecase/related"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RelatedHandler interface {
+	GetRelatedVideos(c *gin.Context)
+	GetRecommendedVideos(c *gin.Context)
+}
+
+type relatedHandler struct {
+	RelatedUC related.UseCase
+}
+
+func NewRelatedHandler(ruc related.UseCase) RelatedHandler {
+	return &relatedHandler{
+		RelatedUC: ruc,
+	}
+}
+
+// GetRelatedVideos godoc
+// @Summary Get related videos
+// @Description Get the videos users who engaged with this video also engaged with, most similar first
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Router /v1/videos/{video_id}/related [get]
+// @Param video_id path string true "Video ID"
+// @Param limit query int false "Limit (1-100, default 10)"
+// @Success 200 {object} []entity.RelatedVideo
+// @Failure 500
+// @Failure 400
+func (h *relatedHandler) GetRelatedVideos(c *gin.Context) {
+	limit, ok := parseRankingLimit(c)
+	if !ok {
+		return
+	}
+	videos, err := h.RelatedUC.ListRelated(c, c.Param("video_id"), limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, videos)
+}
+
+// GetRecommendedVideos godoc
+// @Summary Get recommended videos
+// @Description Get videos related to the ones a user recently engaged with, excluding videos the user already engaged with
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Router /v1/users/{user_id}/related [get]
+// @Param user_id path string true "User ID"
+// @Param limit query int false "Limit (1-100, default 10)"
+// @Success 200 {object} []entity.RelatedVideo
+// @Failure 500
+// @Failure 400
+func (h *relatedHandler) GetRecommendedVideos(c *gin.Context) {
+	limit, ok := parseRankingLimit(c)
+	if !ok {
+		return
+	}
+	videos, err := h.RelatedUC.ListRecommended(c, c.Param("user_id"), limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, videos)
+}
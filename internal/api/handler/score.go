@@ -2,6 +2,9 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
 	"go-server/internal/usecase/score"
 	"strconv"
 
@@ -10,7 +13,10 @@ import (
 
 type ScoreHandler interface {
 	GetGlobalRanking(c *gin.Context)
+	GetTrendingRanking(c *gin.Context)
 	GetPersonalRanking(c *gin.Context)
+	GetVideoRanking(c *gin.Context)
+	BatchGetScores(c *gin.Context)
 }
 
 type scoreHandler struct {
@@ -26,26 +32,100 @@ func NewScoreHandler(scoreUseCase score.UseCase) ScoreHandler {
 
 // GetGlobalRanking godoc
 // @Summary Get global ranking
-// @Description Get global ranking
+// @Description Get a page of the global ranking with each video's rank and score. Pass next_cursor as cursor to get the next page. Filtering by category, creator_id, language or region reads that segment ranking; several filters intersect, scoring each video by its lowest segment score.
 // @Tags rankings
 // @Accept json
 // @Produce json
 // @Router /v1/rankings [get]
-// @Param limit query int false "Limit"
-// @Success 200 {object} []string
+// @Param limit query int false "Limit (1-100, default 10)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort order: top (score, default) or hot (time-decayed score, window=all only)"
+// @Param window query string false "Time window: 1h, 24h, 7d or all (default)"
+// @Param category query string false "Category segment"
+// @Param creator_id query string false "Creator segment"
+// @Param language query string false "Language segment"
+// @Param region query string false "Region segment, where the interactions were sent from"
+// @Success 200 {object} entity.RankingPage
 // @Failure 500
 // @Failure 400
 func (h *scoreHandler) GetGlobalRanking(c *gin.Context) {
-	limit := c.Query("limit")
-	if limit == "" {
-		limit = "10"
+	limit, ok := parseRankingLimit(c)
+	if !ok {
+		return
+	}
+	cursor := c.Query("cursor")
+
+	window := constant.RankingWindow(c.DefaultQuery("window", string(constant.WindowAll)))
+	switch window {
+	case constant.WindowHour, constant.WindowDay, constant.WindowWeek, constant.WindowAll:
+	default:
+		c.AbortWithStatusJSON(400, "Invalid window")
+		return
+	}
+
+	segments := entity.RankingSegments{
+		Category:  c.Query("category"),
+		CreatorID: c.Query("creator_id"),
+		Language:  c.Query("language"),
+		Region:    c.Query("region"),
+	}
+	segmented := segments != entity.RankingSegments{}
+
+	var (
+		ranking *entity.RankingPage
+		err     error
+	)
+	switch c.DefaultQuery("sort", "top") {
+	case "top":
+		if segmented {
+			if window != constant.WindowAll {
+				c.AbortWithStatusJSON(400, "segment filters cannot be combined with a window")
+				return
+			}
+			ranking, err = h.ScoreUseCase.ListSegmentRankedVideos(c, segments, cursor, limit)
+		} else if window == constant.WindowAll {
+			ranking, err = h.ScoreUseCase.ListTopRankedVideos(c, cursor, limit)
+		} else {
+			ranking, err = h.ScoreUseCase.ListWindowRankedVideos(c, window, cursor, limit)
+		}
+	case "hot":
+		if window != constant.WindowAll || segmented {
+			c.AbortWithStatusJSON(400, "sort=hot cannot be combined with a window or segment filters")
+			return
+		}
+		ranking, err = h.ScoreUseCase.ListHotRankedVideos(c, cursor, limit)
+	default:
+		c.AbortWithStatusJSON(400, "Invalid sort")
+		return
+	}
+	if errors.Is(err, score.ErrInvalidCursor) || errors.Is(err, score.ErrSegmentDisabled) {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
 	}
-	limitInt, err := strconv.Atoi(limit)
 	if err != nil {
-		c.AbortWithStatusJSON(400, "Invalid limit")
+		c.AbortWithStatusJSON(500, err.Error())
 		return
 	}
-	ranking, err := h.ScoreUseCase.ListTopRankedVideos(c, limitInt)
+	c.JSON(200, ranking)
+}
+
+// GetTrendingRanking godoc
+// @Summary Get trending ranking
+// @Description Get the videos whose score is growing fastest in the current hour compared to their recent hourly average
+// @Tags rankings
+// @Accept json
+// @Produce json
+// @Router /v1/rankings/trending [get]
+// @Param limit query int false "Limit (1-100, default 10)"
+// @Success 200 {object} []entity.TrendingVideo
+// @Failure 500
+// @Failure 400
+func (h *scoreHandler) GetTrendingRanking(c *gin.Context) {
+	limit, ok := parseRankingLimit(c)
+	if !ok {
+		return
+	}
+	ranking, err := h.ScoreUseCase.ListTrendingVideos(c, limit)
 	if err != nil {
 		c.AbortWithStatusJSON(500, err.Error())
 		return
@@ -55,31 +135,127 @@ func (h *scoreHandler) GetGlobalRanking(c *gin.Context) {
 
 // GetPersonalRanking godoc
 // @Summary Get personal ranking
-// @Description Get personal ranking
+// @Description Get a page of a user's personal ranking with each video's rank and score. Pass next_cursor as cursor to get the next page.
+// @Description With sort=blended, the user's personal scores are mixed with the global and trending rankings, and users without interactions get the global ranking.
 // @Tags rankings
 // @Accept json
 // @Produce json
 // @Router /v1/rankings/{user_id} [get]
 // @Param user_id path string true "User ID"
-// @Param limit query int false "Limit"
-// @Success 200 {object} []string
+// @Param limit query int false "Limit (1-100, default 10)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort order: top (personal score, default) or blended"
+// @Param exclude_watched query bool false "Leave out videos the user interacted with (sort=blended only)"
+// @Success 200 {object} entity.RankingPage
 // @Failure 500
 // @Failure 400
 func (h *scoreHandler) GetPersonalRanking(c *gin.Context) {
 	userID := c.Param("user_id")
-	limit := c.Query("limit")
-	if limit == "" {
-		limit = "10"
+	limit, ok := parseRankingLimit(c)
+	if !ok {
+		return
 	}
-	limitInt, err := strconv.Atoi(limit)
+	excludeWatched, err := strconv.ParseBool(c.DefaultQuery("exclude_watched", "false"))
 	if err != nil {
-		c.AbortWithStatusJSON(400, "Invalid limit")
+		c.AbortWithStatusJSON(400, "Invalid exclude_watched")
+		return
+	}
+
+	var ranking *entity.RankingPage
+	switch c.DefaultQuery("sort", "top") {
+	case "top":
+		if excludeWatched {
+			c.AbortWithStatusJSON(400, "exclude_watched requires sort=blended")
+			return
+		}
+		ranking, err = h.ScoreUseCase.ListPersonalTopRankedVideos(c, userID, c.Query("cursor"), limit)
+	case "blended":
+		ranking, err = h.ScoreUseCase.ListBlendedRankedVideos(c, userID, c.Query("cursor"), limit, excludeWatched)
+	default:
+		c.AbortWithStatusJSON(400, "Invalid sort")
+		return
+	}
+	if errors.Is(err, score.ErrInvalidCursor) {
+		c.AbortWithStatusJSON(400, err.Error())
 		return
 	}
-	ranking, err := h.ScoreUseCase.ListPersonalTopRankedVideos(c, userID, limitInt)
 	if err != nil {
 		c.AbortWithStatusJSON(500, err.Error())
 		return
 	}
 	c.JSON(200, ranking)
 }
+
+// GetVideoRanking godoc
+// @Summary Get a video's rank
+// @Description Get a video's rank, score and percentile in the global ranking and optionally in a user's personal ranking, with up to neighbors videos above and below it
+// @Tags rankings
+// @Accept json
+// @Produce json
+// @Router /v1/rankings/videos/{video_id} [get]
+// @Param video_id path string true "Video ID"
+// @Param user_id query string false "User ID to include the video's personal rank"
+// @Param neighbors query int false "Number of videos above and below to include (0-50, default 0)"
+// @Success 200 {object} entity.VideoRank
+// @Failure 500
+// @Failure 404
+// @Failure 400
+func (h *scoreHandler) GetVideoRanking(c *gin.Context) {
+	videoID := c.Param("video_id")
+	neighbors, err := strconv.Atoi(c.DefaultQuery("neighbors", "0"))
+	if err != nil || neighbors < 0 || neighbors > constant.MaxRankingNeighbors {
+		c.AbortWithStatusJSON(400, "Invalid neighbors, it must be between 0 and "+strconv.Itoa(constant.MaxRankingNeighbors))
+		return
+	}
+
+	rank, err := h.ScoreUseCase.GetVideoRank(c, videoID, c.Query("user_id"), neighbors)
+	if errors.Is(err, score.ErrVideoNotRanked) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, rank)
+}
+
+// BatchGetScores godoc
+// @Summary Get scores of several videos
+// @Description Get the global score and rank of up to 100 videos in one call, and their personal scores when user_id is set. Rank is 0 for videos missing from the cached ranking.
+// @Tags scores
+// @Accept json
+// @Produce json
+// @Router /v1/scores:batchGet [post]
+// @Param request body entity.BatchScoreReq true "Video IDs and optional user ID"
+// @Success 200 {object} []entity.VideoScore
+// @Failure 500
+// @Failure 400
+func (h *scoreHandler) BatchGetScores(c *gin.Context) {
+	var req *entity.BatchScoreReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
+	}
+
+	scores, err := h.ScoreUseCase.BatchGetScores(c, req.VideoIDs, req.UserID)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, scores)
+}
+
+// parseRankingLimit reads the limit query parameter, aborting with 400 if it is not within 1 and MaxRankingLimit
+func parseRankingLimit(c *gin.Context) (int, bool) {
+	limit := c.Query("limit")
+	if limit == "" {
+		return constant.DefaultRankingLimit, true
+	}
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt < 1 || limitInt > constant.MaxRankingLimit {
+		c.AbortWithStatusJSON(400, "Invalid limit, it must be between 1 and "+strconv.Itoa(constant.MaxRankingLimit))
+		return 0, false
+	}
+	return limitInt, true
+}
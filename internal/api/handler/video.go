@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+	"go-server/internal/usecase/video"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VideoHandler interface {
+	ListVideos(c *gin.Context)
+	GetVideo(c *gin.Context)
+	UpsertVideo(c *gin.Context)
+	UpsertVideos(c *gin.Context)
+	DeleteVideo(c *gin.Context)
+}
+
+type videoHandler struct {
+	VideoUC video.UseCase
+}
+
+func NewVideoHandler(vuc video.UseCase) VideoHandler {
+	return &videoHandler{
+		VideoUC: vuc,
+	}
+}
+
+// ListVideos godoc
+// @Summary List videos
+// @Description List the video catalog, most recently updated first, optionally filtered by category, creator, language and status
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/videos [get]
+// @Param category query string false "Category"
+// @Param creator_id query string false "Creator ID"
+// @Param language query string false "Language"
+// @Param status query string false "Status: published or unpublished (default all)"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} []entity.Video
+// @Failure 500
+// @Failure 400
+func (h *videoHandler) ListVideos(c *gin.Context) {
+	filter := entity.VideoFilter{
+		Category:  c.Query("category"),
+		CreatorID: c.Query("creator_id"),
+		Language:  c.Query("language"),
+		Status:    entity.VideoStatus(c.Query("status")),
+	}
+	switch filter.Status {
+	case "", entity.VideoPublished, entity.VideoUnpublished:
+	default:
+		c.AbortWithStatusJSON(400, "Invalid status")
+		return
+	}
+	limit, offset, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+
+	videos, err := h.VideoUC.ListVideos(c, filter, limit, offset)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, videos)
+}
+
+// GetVideo godoc
+// @Summary Get video
+// @Description Get the catalog metadata of a video
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/videos/{video_id} [get]
+// @Param video_id path string true "Video ID"
+// @Success 200 {object} entity.Video
+// @Failure 500
+// @Failure 404
+func (h *videoHandler) GetVideo(c *gin.Context) {
+	metadata, err := h.VideoUC.GetVideo(c, c.Param("video_id"))
+	if errors.Is(err, video.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, metadata)
+}
+
+// UpsertVideo godoc
+// @Summary Create or replace video
+// @Description Create or replace the catalog metadata of a video. Category and language are lowercased, status defaults to published.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/videos/{video_id} [put]
+// @Param video_id path string true "Video ID"
+// @Param request body entity.VideoReq true "Video metadata"
+// @Success 200 {object} string
+// @Success 201 {object} string
+// @Failure 400
+// @Failure 500
+func (h *videoHandler) UpsertVideo(c *gin.Context) {
+	var req entity.VideoReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
+	}
+	req.VideoID = c.Param("video_id")
+
+	created, err := h.VideoUC.UpsertVideo(c, &req)
+	if errors.Is(err, video.ErrInvalidVideo) {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	if created {
+		c.JSON(201, "Video created successfully")
+		return
+	}
+	c.JSON(200, "Video updated successfully")
+}
+
+// UpsertVideos godoc
+// @Summary Create or replace videos in bulk
+// @Description Create or replace the catalog metadata of up to 1000 videos in one request. Invalid items are rejected without failing the rest; when a video appears more than once the last item wins.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/videos:batchUpsert [post]
+// @Param request body []entity.VideoReq true "Videos"
+// @Success 200 {object} entity.BulkVideoResult
+// @Failure 400
+// @Failure 500
+func (h *videoHandler) UpsertVideos(c *gin.Context) {
+	var reqs []*entity.VideoReq
+	if err := c.ShouldBindJSON(&reqs); err != nil {
+		c.AbortWithStatusJSON(400, err.Error())
+		return
+	}
+	if len(reqs) == 0 {
+		c.AbortWithStatusJSON(400, "no videos in request")
+		return
+	}
+	if len(reqs) > constant.MaxBulkVideos {
+		c.AbortWithStatusJSON(400, fmt.Sprintf("at most %d videos per request", constant.MaxBulkVideos))
+		return
+	}
+
+	result, err := h.VideoUC.UpsertVideos(c, reqs)
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, result)
+}
+
+// DeleteVideo godoc
+// @Summary Delete video
+// @Description Remove a video from the catalog, its scores and rankings are left untouched
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/videos/{video_id} [delete]
+// @Param video_id path string true "Video ID"
+// @Success 200 {object} string
+// @Failure 500
+// @Failure 404
+func (h *videoHandler) DeleteVideo(c *gin.Context) {
+	err := h.VideoUC.DeleteVideo(c, c.Param("video_id"))
+	if errors.Is(err, video.ErrNotFound) {
+		c.AbortWithStatusJSON(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(500, err.Error())
+		return
+	}
+	c.JSON(200, "Video deleted successfully")
+}
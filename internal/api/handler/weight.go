@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"go-server/internal/usecase/weight"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WeightHandler interface {
+	GetWeights(c *gin.Context)
+}
+
+type weightHandler struct {
+	WeightUC weight.UseCase
+}
+
+func NewWeightHandler(wuc weight.UseCase) WeightHandler {
+	return &weightHandler{
+		WeightUC: wuc,
+	}
+}
+
+// GetWeights godoc
+// @Summary Get interaction weights
+// @Description Get the active interaction weight table, where it was loaded from and when
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Router /v1/admin/weights [get]
+// @Success 200 {object} entity.WeightTable
+func (h *weightHandler) GetWeights(c *gin.Context) {
+	c.JSON(200, h.WeightUC.GetWeightTable())
+}
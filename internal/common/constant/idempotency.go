@@ -0,0 +1,11 @@
+package constant
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyProcessing IdempotencyStatus = "processing"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+const IdempotencyKeyHeader string = "Idempotency-Key"
+const IdempotentReplayedHeader string = "Idempotent-Replayed"
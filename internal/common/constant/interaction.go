@@ -9,19 +9,26 @@ const (
 	Share   InteractionType = "share"
 )
 
-func (it *InteractionType) GetScore() float64 {
-	switch *it {
-	case View:
-		return 1.0
-	case Like:
-		return 2.0
-	case Comment:
-		return 3.0
-	case Share:
-		return 4.0
-	default:
-		return 0.0
-	}
+// DefaultInteractionWeights is the weight table used when no weights file or env is configured
+var DefaultInteractionWeights = map[InteractionType]float64{
+	View:    1.0,
+	Like:    2.0,
+	Comment: 3.0,
+	Share:   4.0,
 }
 
-const InteractionEventsChannel string = "interaction_events"
+// MaxBulkInteractions caps the number of items in one bulk ingestion request
+const MaxBulkInteractions = 1000
+
+// MaxBulkRequestBytes caps the body of one bulk ingestion request
+const MaxBulkRequestBytes = 8 << 20
+
+// RetractionEventPrefix prefixes the event ID of a retraction to the ID of the interaction it undoes,
+// so retracting twice produces the same event
+const RetractionEventPrefix = "retract:"
+
+// FingerprintHeader carries the client device fingerprint when the request body has none
+const FingerprintHeader string = "X-Client-Fingerprint"
+
+// RegionHeader carries the client region when the request body has none
+const RegionHeader string = "X-Client-Region"
@@ -0,0 +1,38 @@
+package constant
+
+// RankingWindow is the time range a leaderboard aggregates
+type RankingWindow string
+
+const (
+	WindowHour RankingWindow = "1h"
+	WindowDay  RankingWindow = "24h"
+	WindowWeek RankingWindow = "7d"
+	WindowAll  RankingWindow = "all"
+)
+
+const (
+	DefaultRankingLimit = 10
+	MaxRankingLimit     = 100
+)
+
+// MaxRankingNeighbors caps how many videos above and below a video its rank lookup returns
+const MaxRankingNeighbors = 50
+
+// SegmentDimension is a property videos or events are grouped by in segment rankings
+type SegmentDimension string
+
+const (
+	SegmentCategory SegmentDimension = "category"
+	SegmentCreator  SegmentDimension = "creator"
+	SegmentLanguage SegmentDimension = "language"
+	SegmentRegion   SegmentDimension = "region"
+)
+
+// RebuildMode selects whether the Redis rankings are rebuilt from MongoDB at startup
+type RebuildMode string
+
+const (
+	RebuildNever   RebuildMode = "never"
+	RebuildIfEmpty RebuildMode = "if_empty"
+	RebuildAlways  RebuildMode = "always"
+)
@@ -1,4 +1,26 @@
 package constant
 
 const VideoRanking string = "video_ranking"
+const HotVideoRanking string = "video_ranking_hot"
+const HotVideoRankingEpoch string = "video_ranking_hot_epoch"
+const HourlyVideoRankingPrefix string = "video_ranking_hour_"
+const DailyVideoRankingPrefix string = "video_ranking_day_"
+const WindowVideoRankingPrefix string = "video_ranking_window_"
+const TrendingVideoRankingPrefix string = "video_ranking_trending_"
+const SegmentVideoRankingPrefix string = "video_ranking_segment_"
+const SegmentIntersectionPrefix string = "video_ranking_segments_"
 const PersonalRankingPrefix string = "personal_ranking_"
+const IdempotencyKeyPrefix string = "idempotency_"
+const ProcessedEventPrefix string = "processed_event_"
+const InteractionCountPrefix string = "interaction_count_"
+const FraudRatePrefix string = "fraud_rate_events_"
+const FraudFingerprintPrefix string = "fraud_fingerprint_"
+const VideoViewCountPrefix string = "video_view_events_"
+const RebuildKeyPrefix string = "rebuild_"
+const RebuildLease string = "ranking_rebuild_lease"
+const RebuildStatus string = "ranking_rebuild_status"
+const ReconcileLease string = "reconcile_lease"
+const ReconcileLastRun string = "reconcile_last_run"
+const ReconcileTotals string = "reconcile_totals"
+const RelatedVideosPrefix string = "related_videos_"
+const RelatedLease string = "related_lease"
@@ -0,0 +1,16 @@
+package constant
+
+// VideoValidation decides which interactions are rejected based on the video catalog
+type VideoValidation string
+
+const (
+	// VideoValidationOff accepts interactions for any video ID
+	VideoValidationOff VideoValidation = "off"
+	// VideoValidationKnown rejects interactions for videos missing from the catalog
+	VideoValidationKnown VideoValidation = "known"
+	// VideoValidationPublished also rejects interactions for videos that are not published
+	VideoValidationPublished VideoValidation = "published"
+)
+
+// MaxBulkVideos caps the number of videos in one bulk metadata upsert
+const MaxBulkVideos = 1000
@@ -1,11 +1,29 @@
 package util
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 func ToPtr[T any](x T) *T {
 	return &x
 }
 
 func Today() string {
-	return time.Now().Format("2006-01-02")
+	return DayBucket(time.Now())
+}
+
+// DayBucket returns the UTC day t falls in, used to key daily leaderboards
+func DayBucket(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// HourBucket returns the UTC hour t falls in, used to key hourly leaderboards
+func HourBucket(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15")
+}
+
+// NormalizeLabel trims and lowercases a category, language or region so "Music" and "music" match
+func NormalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
 }
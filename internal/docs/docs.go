@@ -15,14 +15,55 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/interactions": {
-            "post": {
-                "security": [
+        "/v1/admin/dead-letters": {
+            "get": {
+                "description": "List interaction events the score consumer gave up on, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List dead letters",
+                "parameters": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
-                "description": "Create new interaction",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.DeadLetter"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/dead-letters/{id}": {
+            "get": {
+                "description": "Get a dead letter with its error, attempt count and original payload",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,28 +71,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "interaction"
+                    "admin"
                 ],
-                "summary": "Create new interaction",
+                "summary": "Get dead letter",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
+                        "description": "Dead letter ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.DeadLetter"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
                     },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a dead letter without replaying it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Discard dead letter",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
-                        "name": "post_id",
+                        "description": "Dead letter ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
                     },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/dead-letters/{id}/redrive": {
+            "post": {
+                "description": "Hand the original event of a dead letter back to the outbox relay and remove the dead letter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Re-drive dead letter",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Interaction Type",
-                        "name": "interaction_type",
+                        "description": "Dead letter ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -63,15 +164,21 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/v1/rankings": {
+        "/v1/admin/flagged-users": {
             "get": {
-                "description": "Get global ranking",
+                "description": "List users suspected of fraud, most recently flagged first",
                 "consumes": [
                     "application/json"
                 ],
@@ -79,15 +186,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rankings"
+                    "admin"
                 ],
-                "summary": "Get global ranking",
+                "summary": "List flagged users",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Limit",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -96,7 +209,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/entity.FlaggedUser"
                             }
                         }
                     },
@@ -109,9 +222,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/rankings/{user_id}": {
-            "get": {
-                "description": "Get personal ranking",
+        "/v1/admin/flagged-users/{user_id}": {
+            "put": {
+                "description": "Flag a user as suspicious, all of their later events are quarantined",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,9 +232,86 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rankings"
+                    "admin"
                 ],
-                "summary": "Get personal ranking",
+                "summary": "Flag user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason",
+                        "name": "reason",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the flag of a user, events already in quarantine stay there",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unflag user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/flagged-users/{user_id}/subtract": {
+            "post": {
+                "description": "Remove everything a flagged user added to the video scores and the global, hot, windowed, trending and segment rankings, and reset their personal ranking",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Subtract flagged user's contribution",
                 "parameters": [
                     {
                         "type": "string",
@@ -129,12 +319,58 @@ const docTemplate = `{
                         "name": "user_id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/quarantine": {
+            "get": {
+                "description": "List interaction events held back by fraud detection, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List quarantined events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Status: held or released (default all)",
+                        "name": "status",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
                         "description": "Limit",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -143,7 +379,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/entity.QuarantinedEvent"
                             }
                         }
                     },
@@ -155,6 +391,1514 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/v1/admin/quarantine/{id}": {
+            "delete": {
+                "description": "Remove a quarantined event without applying it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purge quarantined event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quarantined event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/quarantine/{id}/release": {
+            "post": {
+                "description": "Publish a quarantined event again so the score consumer applies it without fraud screening",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Release quarantined event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quarantined event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/rankings/rebuild": {
+            "get": {
+                "description": "Get the progress of the running or last ranking rebuild of any instance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get rebuild status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.RebuildStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Start rebuilding the global and personal Redis rankings from the MongoDB scores in the background",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Rebuild rankings",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/entity.RebuildStatus"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/rankings/reconciliation": {
+            "get": {
+                "description": "Get the drift between the MongoDB video scores and the Redis global ranking found by the reconciler, summed over all passes and for the last pass",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get reconciliation drift",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ReconcileStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/videos": {
+            "get": {
+                "description": "List the video catalog, most recently updated first, optionally filtered by category, creator, language and status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creator ID",
+                        "name": "creator_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status: published or unpublished (default all)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Video"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/videos/{video_id}": {
+            "get": {
+                "description": "Get the catalog metadata of a video",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Video"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace the catalog metadata of a video. Category and language are lowercased, status defaults to published.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create or replace video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Video metadata",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.VideoReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a video from the catalog, its scores and rankings are left untouched",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/videos:batchUpsert": {
+            "post": {
+                "description": "Create or replace the catalog metadata of up to 1000 videos in one request. Invalid items are rejected without failing the rest; when a video appears more than once the last item wins.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create or replace videos in bulk",
+                "parameters": [
+                    {
+                        "description": "Videos",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.VideoReq"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BulkVideoResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/weights": {
+            "get": {
+                "description": "Get the active interaction weight table, where it was loaded from and when",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get interaction weights",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WeightTable"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/interactions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new interaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interaction"
+                ],
+                "summary": "Create new interaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interaction Type",
+                        "name": "interaction_type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key, replays the original response when reused",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client device fingerprint, used by fraud detection",
+                        "name": "X-Client-Fingerprint",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client region code such as vn or us-ca, used by the region rankings",
+                        "name": "X-Client-Region",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/interactions/{event_id}": {
+            "delete": {
+                "description": "Undo an interaction (unlike, delete comment, unshare). The interaction is marked as retracted and its score is subtracted from the rankings asynchronously, never taking a score below zero.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interaction"
+                ],
+                "summary": "Retract an interaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID of the interaction, or the idempotency key it was sent with",
+                        "name": "event_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID that made the interaction",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/interactions:batchCreate": {
+            "post": {
+                "description": "Create up to 1000 interactions in one request, sent as a JSON array or as NDJSON (one interaction per line, Content-Type application/x-ndjson). Each item is validated on its own; the response lists which items were accepted, replayed (already processed event_id) or rejected and why.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "interaction"
+                ],
+                "summary": "Create interactions in bulk",
+                "parameters": [
+                    {
+                        "description": "Interactions",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.UserInteractionReq"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BulkInteractionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/rankings": {
+            "get": {
+                "description": "Get a page of the global ranking with each video's rank and score. Pass next_cursor as cursor to get the next page. Filtering by category, creator_id, language or region reads that segment ranking; several filters intersect, scoring each video by its lowest segment score.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rankings"
+                ],
+                "summary": "Get global ranking",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit (1-100, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: top (score, default) or hot (time-decayed score, window=all only)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time window: 1h, 24h, 7d or all (default)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category segment",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creator segment",
+                        "name": "creator_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language segment",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Region segment, where the interactions were sent from",
+                        "name": "region",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.RankingPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/rankings/trending": {
+            "get": {
+                "description": "Get the videos whose score is growing fastest in the current hour compared to their recent hourly average",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rankings"
+                ],
+                "summary": "Get trending ranking",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit (1-100, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.TrendingVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/rankings/videos/{video_id}": {
+            "get": {
+                "description": "Get a video's rank, score and percentile in the global ranking and optionally in a user's personal ranking, with up to neighbors videos above and below it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rankings"
+                ],
+                "summary": "Get a video's rank",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID to include the video's personal rank",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of videos above and below to include (0-50, default 0)",
+                        "name": "neighbors",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.VideoRank"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/rankings/{user_id}": {
+            "get": {
+                "description": "Get a page of a user's personal ranking with each video's rank and score. Pass next_cursor as cursor to get the next page.\nWith sort=blended, the user's personal scores are mixed with the global and trending rankings, and users without interactions get the global ranking.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rankings"
+                ],
+                "summary": "Get personal ranking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (1-100, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: top (personal score, default) or blended",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out videos the user interacted with (sort=blended only)",
+                        "name": "exclude_watched",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.RankingPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/scores:batchGet": {
+            "post": {
+                "description": "Get the global score and rank of up to 100 videos in one call, and their personal scores when user_id is set. Rank is 0 for videos missing from the cached ranking.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scores"
+                ],
+                "summary": "Get scores of several videos",
+                "parameters": [
+                    {
+                        "description": "Video IDs and optional user ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.BatchScoreReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.VideoScore"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/users/{user_id}/related": {
+            "get": {
+                "description": "Get videos related to the ones a user recently engaged with, excluding videos the user already engaged with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Get recommended videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (1-100, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.RelatedVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/videos/{video_id}/related": {
+            "get": {
+                "description": "Get the videos users who engaged with this video also engaged with, most similar first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Get related videos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Video ID",
+                        "name": "video_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (1-100, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.RelatedVideo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.BatchScoreReq": {
+            "type": "object",
+            "required": [
+                "video_ids"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                },
+                "video_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "entity.BulkInteractionResult": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BulkItemResult"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "replayed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.BulkItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.BulkItemStatus"
+                }
+            }
+        },
+        "entity.BulkItemStatus": {
+            "type": "string",
+            "enum": [
+                "accepted",
+                "replayed",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "BulkItemAccepted",
+                "BulkItemReplayed",
+                "BulkItemRejected"
+            ]
+        },
+        "entity.BulkVideoResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BulkItemResult"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "transport": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.FlaggedUser": {
+            "type": "object",
+            "properties": {
+                "flagged_at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.InteractionEvent": {
+            "type": "object",
+            "properties": {
+                "event_id": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "Fingerprint identifies the client device, it is used by fraud detection",
+                    "type": "string"
+                },
+                "multiplier": {
+                    "description": "Multiplier scales the interaction's weight when an anti-spam rule discounted it, nil means full weight",
+                    "type": "number"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "reaction_type": {
+                    "type": "string"
+                },
+                "region": {
+                    "description": "Region is where the client sent the interaction from, it feeds the region segment rankings",
+                    "type": "string"
+                },
+                "retracts_event_id": {
+                    "description": "RetractsEventID is set on retraction events to the ID of the interaction they undo.\nOccurredAt is then the original interaction's time, so it is removed from the same buckets.",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.QuarantineStatus": {
+            "type": "string",
+            "enum": [
+                "held",
+                "released"
+            ],
+            "x-enum-varnames": [
+                "QuarantineHeld",
+                "QuarantineReleased"
+            ]
+        },
+        "entity.QuarantinedEvent": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/entity.InteractionEvent"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "released_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.QuarantineStatus"
+                }
+            }
+        },
+        "entity.RankedVideo": {
+            "type": "object",
+            "properties": {
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.RankingPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RankedVideo"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.RebuildState": {
+            "type": "string",
+            "enum": [
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "RebuildRunning",
+                "RebuildCompleted",
+                "RebuildFailed"
+            ]
+        },
+        "entity.RebuildStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "personal_entries": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/entity.RebuildState"
+                },
+                "users": {
+                    "type": "integer"
+                },
+                "videos": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ReconcileRun": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "max_drift": {
+                    "type": "number"
+                },
+                "mismatched": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "repaired": {
+                    "type": "integer"
+                },
+                "stale": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "total_drift": {
+                    "type": "number"
+                }
+            }
+        },
+        "entity.ReconcileStats": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "integer"
+                },
+                "last_run": {
+                    "$ref": "#/definitions/entity.ReconcileRun"
+                },
+                "mismatched": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "repaired": {
+                    "type": "integer"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "stale": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.RelatedVideo": {
+            "type": "object",
+            "properties": {
+                "score": {
+                    "type": "number"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TrendingVideo": {
+            "type": "object",
+            "properties": {
+                "velocity": {
+                    "description": "Velocity is the current hour's score relative to the average hourly score of the baseline",
+                    "type": "number"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.UserInteractionReq": {
+            "type": "object",
+            "required": [
+                "reaction_at",
+                "reaction_type",
+                "user_id",
+                "video_id"
+            ],
+            "properties": {
+                "event_id": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "type": "string"
+                },
+                "reaction_at": {
+                    "type": "string"
+                },
+                "reaction_type": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Video": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.VideoStatus"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.VideoRank": {
+            "type": "object",
+            "properties": {
+                "global": {
+                    "$ref": "#/definitions/entity.VideoStanding"
+                },
+                "personal": {
+                    "$ref": "#/definitions/entity.VideoStanding"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.VideoReq": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.VideoStatus"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.VideoScore": {
+            "type": "object",
+            "properties": {
+                "personal_score": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "video_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.VideoStanding": {
+            "type": "object",
+            "properties": {
+                "above": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RankedVideo"
+                    }
+                },
+                "below": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RankedVideo"
+                    }
+                },
+                "percentile": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.VideoStatus": {
+            "type": "string",
+            "enum": [
+                "published",
+                "unpublished"
+            ],
+            "x-enum-varnames": [
+                "VideoPublished",
+                "VideoUnpublished"
+            ]
+        },
+        "entity.WeightTable": {
+            "type": "object",
+            "properties": {
+                "loaded_at": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetter is an interaction event that could not be decoded or kept failing in the score consumer
+type DeadLetter struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Transport string             `bson:"transport" json:"transport"`
+	MessageID string             `bson:"message_id" json:"message_id"`
+	Payload   string             `bson:"payload" json:"payload"`
+	Error     string             `bson:"error" json:"error"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QuarantineStatus string
+
+const (
+	QuarantineHeld     QuarantineStatus = "held"
+	QuarantineReleased QuarantineStatus = "released"
+)
+
+// QuarantinedEvent is an interaction event that fraud detection held back from the scores
+type QuarantinedEvent struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Event      InteractionEvent   `bson:"event" json:"event"`
+	Reason     string             `bson:"reason" json:"reason"`
+	Status     QuarantineStatus   `bson:"status" json:"status"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ReleasedAt *time.Time         `bson:"released_at,omitempty" json:"released_at,omitempty"`
+}
+
+// FlaggedUser is a user suspected of fraud, all of their events are quarantined
+type FlaggedUser struct {
+	UserID    string    `bson:"_id" json:"user_id"`
+	Reason    string    `bson:"reason" json:"reason"`
+	FlaggedAt time.Time `bson:"flagged_at" json:"flagged_at"`
+}
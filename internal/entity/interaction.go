@@ -6,22 +6,78 @@ import (
 )
 
 type UserInteractionReq struct {
-	InteractionType interactionConstant.InteractionType `json:"reaction_type" validate:"required"`
+	EventID         string                              `json:"event_id"`
+	InteractionType interactionConstant.InteractionType `json:"reaction_type" validate:"required" swaggertype:"string"`
 	UserID          string                              `json:"user_id" validate:"required"`
 	VideoID         string                              `json:"video_id" validate:"required"`
 	ReactionAt      time.Time                           `json:"reaction_at" validate:"required"`
+	Fingerprint     string                              `json:"fingerprint"`
+	Region          string                              `json:"region"`
 }
 
 type InteractionEvent struct {
-	UserID          string                              `json:"user_id"`
-	VideoID         string                              `json:"video_id"`
-	InteractionType interactionConstant.InteractionType `json:"reaction_type"`
-	PublishedAt     time.Time                           `json:"published_at"`
+	EventID         string                              `bson:"event_id" json:"event_id"`
+	UserID          string                              `bson:"user_id" json:"user_id"`
+	VideoID         string                              `bson:"video_id" json:"video_id"`
+	InteractionType interactionConstant.InteractionType `bson:"interaction_type" json:"reaction_type" swaggertype:"string"`
+	OccurredAt      time.Time                           `bson:"occurred_at" json:"occurred_at"`
+	PublishedAt     time.Time                           `bson:"published_at" json:"published_at"`
+	// RetractsEventID is set on retraction events to the ID of the interaction they undo.
+	// OccurredAt is then the original interaction's time, so it is removed from the same buckets.
+	RetractsEventID string `bson:"retracts_event_id,omitempty" json:"retracts_event_id,omitempty"`
+	// Multiplier scales the interaction's weight when an anti-spam rule discounted it, nil means full weight
+	Multiplier *float64 `bson:"multiplier,omitempty" json:"multiplier,omitempty"`
+	// Fingerprint identifies the client device, it is used by fraud detection
+	Fingerprint string `bson:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+	// Region is where the client sent the interaction from, it feeds the region segment rankings
+	Region string `bson:"region,omitempty" json:"region,omitempty"`
 }
 
 type Interaction struct {
+	EventID         string                              `bson:"event_id" json:"event_id"`
 	UserID          string                              `bson:"user_id" json:"user_id"`
 	VideoID         string                              `bson:"video_id" json:"video_id"`
 	InteractionType interactionConstant.InteractionType `bson:"interaction_type" json:"interaction_type"`
 	CreatedAt       time.Time                           `bson:"created_at" json:"created_at"`
+	RetractedAt     *time.Time                          `bson:"retracted_at,omitempty" json:"retracted_at,omitempty"`
+	Multiplier      *float64                            `bson:"multiplier,omitempty" json:"multiplier,omitempty"`
+	Fingerprint     string                              `bson:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+	Region          string                              `bson:"region,omitempty" json:"region,omitempty"`
+}
+
+type BulkItemStatus string
+
+const (
+	BulkItemAccepted BulkItemStatus = "accepted"
+	BulkItemReplayed BulkItemStatus = "replayed"
+	BulkItemRejected BulkItemStatus = "rejected"
+)
+
+// BulkItemResult is the outcome of one item of a bulk ingestion request, Index is its position in the request
+type BulkItemResult struct {
+	Index   int            `json:"index"`
+	EventID string         `json:"event_id,omitempty"`
+	Status  BulkItemStatus `json:"status"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// BulkInteractionResult summarizes a bulk ingestion request with the outcome of every item
+type BulkInteractionResult struct {
+	Accepted int               `json:"accepted"`
+	Replayed int               `json:"replayed"`
+	Rejected int               `json:"rejected"`
+	Items    []*BulkItemResult `json:"items"`
+}
+
+// Add records the outcome of an item and updates the counters
+func (r *BulkInteractionResult) Add(item *BulkItemResult) {
+	switch item.Status {
+	case BulkItemAccepted:
+		r.Accepted++
+	case BulkItemReplayed:
+		r.Replayed++
+	case BulkItemRejected:
+		r.Rejected++
+	}
+	r.Items = append(r.Items, item)
 }
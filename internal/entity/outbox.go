@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+)
+
+// OutboxMessage is an interaction event waiting to be published by the outbox relay
+type OutboxMessage struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Event         InteractionEvent   `bson:"event" json:"event"`
+	Status        OutboxStatus       `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LeaseToken    string             `bson:"lease_token,omitempty" json:"-"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+type RebuildState string
+
+const (
+	RebuildRunning   RebuildState = "running"
+	RebuildCompleted RebuildState = "completed"
+	RebuildFailed    RebuildState = "failed"
+)
+
+// RebuildStatus reports the progress of rebuilding the Redis rankings from MongoDB on Instance
+type RebuildStatus struct {
+	State           RebuildState `json:"state"`
+	Instance        string       `json:"instance"`
+	Videos          int64        `json:"videos"`
+	Users           int64        `json:"users"`
+	PersonalEntries int64        `json:"personal_entries"`
+	StartedAt       time.Time    `json:"started_at"`
+	FinishedAt      *time.Time   `json:"finished_at,omitempty"`
+	Error           string       `json:"error,omitempty"`
+}
@@ -0,0 +1,59 @@
+package entity
+
+import "time"
+
+// RecomputeReq selects what a score recompute replays. From and To bound the interaction time,
+// nil bounds are open. ExcludeFlagged skips every interaction of a currently flagged user.
+type RecomputeReq struct {
+	From           *time.Time `bson:"from,omitempty" json:"from,omitempty"`
+	To             *time.Time `bson:"to,omitempty" json:"to,omitempty"`
+	ExcludeFlagged bool       `bson:"exclude_flagged" json:"exclude_flagged"`
+}
+
+// Full reports whether the request replays the whole interaction log
+func (req *RecomputeReq) Full() bool {
+	return req.From == nil && req.To == nil
+}
+
+// RecomputeRun records the finished recompute whose scores are waiting to be swapped in
+type RecomputeRun struct {
+	Request    RecomputeReq `bson:"request" json:"request"`
+	StartedAt  time.Time    `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time    `bson:"finished_at" json:"finished_at"`
+}
+
+// ScoreDiff is the difference between the current and the recomputed score of a video, or of a user/video pair
+type ScoreDiff struct {
+	UserID     string  `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	VideoID    string  `bson:"video_id" json:"video_id"`
+	Current    float64 `bson:"current" json:"current"`
+	Recomputed float64 `bson:"recomputed" json:"recomputed"`
+	Difference float64 `bson:"difference" json:"difference"`
+}
+
+// ScoreDiffSummary compares a current score collection with its recomputed counterpart.
+// Added scores only exist in the recomputed collection, Removed ones only in the current one.
+// Largest holds the scores that changed the most, largest absolute difference first.
+type ScoreDiffSummary struct {
+	Current         int64        `bson:"current" json:"current"`
+	Recomputed      int64        `bson:"recomputed" json:"recomputed"`
+	Added           int64        `bson:"added" json:"added"`
+	Removed         int64        `bson:"removed" json:"removed"`
+	Changed         int64        `bson:"changed" json:"changed"`
+	CurrentTotal    float64      `bson:"current_total" json:"current_total"`
+	RecomputedTotal float64      `bson:"recomputed_total" json:"recomputed_total"`
+	Largest         []*ScoreDiff `bson:"largest" json:"largest"`
+}
+
+// RecomputeReport describes a score recompute and how its result differs from the current scores.
+// Skipped counts interactions that were retracted, are held in quarantine or belong to excluded users.
+type RecomputeReport struct {
+	Request    RecomputeReq      `json:"request"`
+	Weights    *WeightTable      `json:"weights"`
+	Replayed   int64             `json:"replayed"`
+	Skipped    int64             `json:"skipped"`
+	Videos     *ScoreDiffSummary `json:"videos"`
+	Personal   *ScoreDiffSummary `json:"personal"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// ReconcileRun reports one reconciliation pass of the global ranking against the video scores.
+// Missing videos are scored in MongoDB but absent from the ranking, stale ones are ranked without
+// a score document. Pending videos drift because an applied event has not reached Redis yet, they are
+// left to its redelivery. Drift is the absolute difference between the two scores of a repaired video.
+type ReconcileRun struct {
+	Instance   string    `json:"instance"`
+	Checked    int64     `json:"checked"`
+	Mismatched int64     `json:"mismatched"`
+	Missing    int64     `json:"missing"`
+	Stale      int64     `json:"stale"`
+	Pending    int64     `json:"pending"`
+	Repaired   int64     `json:"repaired"`
+	MaxDrift   float64   `json:"max_drift"`
+	TotalDrift float64   `json:"total_drift"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Drift records a drifting video found by a pass
+func (r *ReconcileRun) Drift(drift float64) {
+	r.TotalDrift += drift
+	r.MaxDrift = max(r.MaxDrift, drift)
+}
+
+// ReconcileStats holds the reconciliation counters summed over all passes of all instances, and the last pass
+type ReconcileStats struct {
+	Runs       int64         `json:"runs" redis:"runs"`
+	Checked    int64         `json:"checked" redis:"checked"`
+	Mismatched int64         `json:"mismatched" redis:"mismatched"`
+	Missing    int64         `json:"missing" redis:"missing"`
+	Stale      int64         `json:"stale" redis:"stale"`
+	Pending    int64         `json:"pending" redis:"pending"`
+	Repaired   int64         `json:"repaired" redis:"repaired"`
+	LastRun    *ReconcileRun `json:"last_run,omitempty" redis:"-"`
+}
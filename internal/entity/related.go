@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// RelatedVideo is a video recommended next to another video or to a user. Score is the co-engagement
+// similarity for a related video, and the summed similarity to the user's recent videos for a recommendation.
+type RelatedVideo struct {
+	VideoID string  `json:"video_id"`
+	Score   float64 `json:"score"`
+}
+
+// RelatedReport summarizes a run of the related videos job. Pruned counts the co-engagement counts
+// dropped to keep each video under the cap of co-engaged videos.
+type RelatedReport struct {
+	Users      int64     `json:"users"`
+	Videos     int64     `json:"videos"`
+	Pairs      int64     `json:"pairs"`
+	Pruned     int64     `json:"pruned"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
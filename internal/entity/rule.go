@@ -0,0 +1,40 @@
+package entity
+
+import (
+	interactionConstant "go-server/internal/common/constant"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InteractionRule limits how much one user's interactions of a type count towards one video.
+// Within a window of WindowSeconds (0 means forever) only the first Limit interactions are accepted,
+// and every interaction after the DecayAfter-th is worth DecayFactor times the previous one.
+// A zero Limit or DecayAfter disables that part of the rule.
+type InteractionRule struct {
+	Limit         int64   `json:"limit,omitempty"`
+	WindowSeconds int64   `json:"window_seconds,omitempty"`
+	DecayAfter    int64   `json:"decay_after,omitempty"`
+	DecayFactor   float64 `json:"decay_factor,omitempty"`
+}
+
+type AuditAction string
+
+const (
+	AuditRejected   AuditAction = "rejected"
+	AuditDiscounted AuditAction = "discounted"
+)
+
+// InteractionAudit records an interaction that a rule rejected or discounted, and why
+type InteractionAudit struct {
+	ID              primitive.ObjectID                  `bson:"_id,omitempty" json:"id"`
+	EventID         string                              `bson:"event_id" json:"event_id"`
+	UserID          string                              `bson:"user_id" json:"user_id"`
+	VideoID         string                              `bson:"video_id" json:"video_id"`
+	InteractionType interactionConstant.InteractionType `bson:"interaction_type" json:"interaction_type"`
+	Action          AuditAction                         `bson:"action" json:"action"`
+	Reason          string                              `bson:"reason" json:"reason"`
+	Count           int64                               `bson:"count" json:"count"`
+	Multiplier      float64                             `bson:"multiplier" json:"multiplier"`
+	CreatedAt       time.Time                           `bson:"created_at" json:"created_at"`
+}
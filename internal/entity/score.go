@@ -0,0 +1,114 @@
+package entity
+
+import (
+	"time"
+
+	"go-server/internal/common/constant"
+)
+
+// ProcessedEvent is the ledger entry recording that an interaction event was applied to the scores
+type ProcessedEvent struct {
+	EventID     string    `bson:"_id" json:"event_id"`
+	UserID      string    `bson:"user_id" json:"user_id"`
+	VideoID     string    `bson:"video_id" json:"video_id"`
+	Delta       float64   `bson:"delta" json:"delta"`
+	OccurredAt  time.Time `bson:"occurred_at" json:"occurred_at"`
+	ProcessedAt time.Time `bson:"processed_at" json:"processed_at"`
+	// Segments are the segment rankings the event was applied to, so a retraction leaves the same ones
+	Segments []string `bson:"segments,omitempty" json:"segments,omitempty"`
+	// SubtractedAt is set when the event was taken out of the scores with its flagged user's contribution,
+	// or on the tombstone of an event retracted before it was applied
+	SubtractedAt *time.Time `bson:"subtracted_at,omitempty" json:"subtracted_at,omitempty"`
+}
+
+// Segment names the segment ranking of a dimension value, e.g. "category:gaming"
+func Segment(dimension constant.SegmentDimension, value string) string {
+	return string(dimension) + ":" + value
+}
+
+// RankingSegments selects a segment ranking, empty fields match any value and several set fields intersect
+type RankingSegments struct {
+	Category  string
+	CreatorID string
+	Language  string
+	Region    string
+}
+
+// RankingPolicy controls how a processed event is applied to the Redis rankings
+type RankingPolicy struct {
+	// MarkerTTL is how long the processed marker guarding the rankings is kept
+	MarkerTTL time.Duration
+	// HotHalfLife is the time after which an event's contribution to the hot ranking halves
+	HotHalfLife time.Duration
+	// HourBucketTTL and DayBucketTTL are how long hourly and daily leaderboard buckets are kept
+	HourBucketTTL time.Duration
+	DayBucketTTL  time.Duration
+	// TrendingBaselineHours is how many hourly buckets before the event's hour form the trending baseline
+	TrendingBaselineHours int
+	// TrendingSmoothing is added to both sides of the velocity ratio so sparse videos do not spike
+	TrendingSmoothing float64
+	// PersonalMaxSize is the number of top videos kept in a personal ranking and PersonalTTL how long
+	// a personal ranking is kept without activity, 0 disabling either
+	PersonalMaxSize int64
+	PersonalTTL     time.Duration
+}
+
+// TrendingVideo is a video ranked by how fast its score is growing
+type TrendingVideo struct {
+	VideoID string `json:"video_id"`
+	// Velocity is the current hour's score relative to the average hourly score of the baseline
+	Velocity float64 `json:"velocity"`
+}
+
+// RankedVideo is a video at a position in a ranking
+type RankedVideo struct {
+	Rank    int64   `json:"rank"`
+	VideoID string  `json:"video_id"`
+	Score   float64 `json:"score"`
+}
+
+// RankingCursor points after the last video of a ranking page. It holds the video's
+// stored score rather than its position, so pages stay consistent while scores move.
+type RankingCursor struct {
+	Score   float64 `json:"s"`
+	VideoID string  `json:"v"`
+}
+
+// RankingPage is a page of a ranking with the cursor of the next page, empty on the last page
+type RankingPage struct {
+	Items      []*RankedVideo `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// VideoStanding is a video's position in one ranking. Percentile is the share of ranked videos
+// at or below the video, so the top video is at 100. Above and Below hold its neighbors, nearest last and first.
+type VideoStanding struct {
+	Rank       int64          `json:"rank"`
+	Score      float64        `json:"score"`
+	Percentile float64        `json:"percentile"`
+	Total      int64          `json:"total"`
+	Above      []*RankedVideo `json:"above,omitempty"`
+	Below      []*RankedVideo `json:"below,omitempty"`
+}
+
+// VideoRank is a video's standing in the global ranking and, when requested, in a user's personal ranking
+type VideoRank struct {
+	VideoID  string         `json:"video_id"`
+	Global   *VideoStanding `json:"global"`
+	Personal *VideoStanding `json:"personal,omitempty"`
+}
+
+// BatchScoreReq asks for the scores of several videos, and their personal scores when UserID is set
+type BatchScoreReq struct {
+	VideoIDs []string `json:"video_ids" binding:"required,min=1,max=100,dive,required"`
+	UserID   string   `json:"user_id"`
+}
+
+// VideoScore is a video's global score and rank. Rank is 0 when the video is missing from the cached
+// ranking, and PersonalScore is only set when a user was given.
+type VideoScore struct {
+	VideoID       string   `json:"video_id"`
+	Score         float64  `json:"score"`
+	Rank          int64    `json:"rank"`
+	PersonalScore *float64 `json:"personal_score,omitempty"`
+}
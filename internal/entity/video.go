@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+type VideoStatus string
+
+const (
+	VideoPublished   VideoStatus = "published"
+	VideoUnpublished VideoStatus = "unpublished"
+)
+
+// Video is the catalog metadata of a video, used to filter and group rankings
+type Video struct {
+	VideoID   string      `bson:"_id" json:"video_id"`
+	Title     string      `bson:"title" json:"title"`
+	Category  string      `bson:"category" json:"category"`
+	CreatorID string      `bson:"creator_id" json:"creator_id"`
+	Language  string      `bson:"language" json:"language"`
+	Status    VideoStatus `bson:"status" json:"status"`
+	CreatedAt time.Time   `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time   `bson:"updated_at" json:"updated_at"`
+}
+
+// VideoReq is the metadata of a video to create or replace, Status defaults to published
+type VideoReq struct {
+	VideoID   string      `json:"video_id"`
+	Title     string      `json:"title"`
+	Category  string      `json:"category"`
+	CreatorID string      `json:"creator_id"`
+	Language  string      `json:"language"`
+	Status    VideoStatus `json:"status"`
+}
+
+// VideoFilter selects catalog videos, empty fields match any value
+type VideoFilter struct {
+	Category  string
+	CreatorID string
+	Language  string
+	Status    VideoStatus
+}
+
+// BulkVideoResult summarizes a bulk metadata upsert, Errors lists the rejected items by their position in the request
+type BulkVideoResult struct {
+	Created  int64             `json:"created"`
+	Updated  int64             `json:"updated"`
+	Rejected int               `json:"rejected"`
+	Errors   []*BulkItemResult `json:"errors,omitempty"`
+}
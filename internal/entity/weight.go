@@ -0,0 +1,13 @@
+package entity
+
+import (
+	interactionConstant "go-server/internal/common/constant"
+	"time"
+)
+
+// WeightTable maps every accepted interaction type to the score it contributes
+type WeightTable struct {
+	Weights  map[interactionConstant.InteractionType]float64 `json:"weights"`
+	Source   string                                          `json:"source"`
+	LoadedAt time.Time                                       `json:"loaded_at"`
+}
@@ -0,0 +1,79 @@
+// Package eventbus implements event.EventBus on top of different transports
+package eventbus
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+)
+
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+	DriverKafka  = "kafka"
+)
+
+// maxDeadLetterBackoff caps the wait between attempts to store a dead letter
+const maxDeadLetterBackoff = 30 * time.Second
+
+// handleWithRetry runs handler until it succeeds, maxRetries is exhausted or ctx is done.
+// It is used by transports that cannot leave a single message pending, the backoff doubles on every attempt.
+func handleWithRetry(ctx context.Context, handler event.Handler, evt *entity.InteractionEvent, maxRetries int, backoff time.Duration) error {
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err = handler(ctx, evt); err == nil {
+			return nil
+		}
+		log.Printf("Failed to handle event for user %s on video %s (attempt %d): %v", evt.UserID, evt.VideoID, attempt+1, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff << attempt):
+		}
+	}
+	return err
+}
+
+// deadLetter hands a message the subscriber gave up on to the dead letter store
+func deadLetter(
+	ctx context.Context, store event.DeadLetterStore, transport string, messageID string, payload []byte, cause error, attempts int,
+) error {
+	return store.Insert(ctx, &entity.DeadLetter{
+		Transport: transport,
+		MessageID: messageID,
+		Payload:   string(payload),
+		Error:     cause.Error(),
+		Attempts:  attempts,
+		CreatedAt: time.Now(),
+	})
+}
+
+// deadLetterWithRetry stores a dead letter until it succeeds or ctx is done. It is used by transports
+// that lose a message once they move past it, the wait doubles from backoff up to maxDeadLetterBackoff.
+func deadLetterWithRetry(
+	ctx context.Context, store event.DeadLetterStore, transport string, messageID string, payload []byte, cause error,
+	attempts int, backoff time.Duration,
+) error {
+	wait := backoff
+	if wait <= 0 {
+		wait = time.Second
+	}
+	for {
+		err := deadLetter(ctx, store, transport, messageID, payload, cause, attempts)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to dead letter message %s, retrying in %s: %v", messageID, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxDeadLetterBackoff)
+	}
+}
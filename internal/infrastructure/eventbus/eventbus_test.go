@@ -0,0 +1,211 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+)
+
+// testTimeout bounds how long a contract test waits for the bus to deliver, a broker may take a few
+// seconds to create a topic or rebalance a consumer group
+const testTimeout = 30 * time.Second
+
+// errHandler is the error returned by failing handlers and dead letter stores
+var errHandler = errors.New("handler failed")
+
+// fakeDeadLetters is a dead letter store that fails its first `failures` inserts and records the rest
+type fakeDeadLetters struct {
+	mu       sync.Mutex
+	failures int
+	tries    int
+	letters  []*entity.DeadLetter
+	stored   chan struct{}
+}
+
+func newFakeDeadLetters(failures int) *fakeDeadLetters {
+	return &fakeDeadLetters{failures: failures, stored: make(chan struct{}, 16)}
+}
+
+func (s *fakeDeadLetters) Insert(ctx context.Context, letter *entity.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tries++
+	if s.tries <= s.failures {
+		return errHandler
+	}
+	s.letters = append(s.letters, letter)
+	s.stored <- struct{}{}
+	return nil
+}
+
+func (s *fakeDeadLetters) snapshot() (int, []*entity.DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tries, append([]*entity.DeadLetter(nil), s.letters...)
+}
+
+// busFactory creates a bus under test with the given dead letter store and retry budget.
+// Every call must return a bus on its own stream or topic, so subtests do not see each other's events.
+type busFactory func(t *testing.T, store event.DeadLetterStore, maxRetries int) event.EventBus
+
+// subscribe runs Subscribe in the background and returns a function that stops it and returns its error
+func subscribe(t *testing.T, bus event.EventBus, handler event.Handler) func() error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bus.Subscribe(ctx, handler) }()
+	return func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(testTimeout):
+			t.Fatal("Subscribe did not return after its context was canceled")
+			return nil
+		}
+	}
+}
+
+// wait blocks until ch receives or the test times out
+func wait[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out waiting for %s", what)
+		var zero T
+		return zero
+	}
+}
+
+// payloadEventID decodes the event ID of a dead letter's payload, message IDs differ between drivers
+func payloadEventID(letter *entity.DeadLetter) string {
+	var evt entity.InteractionEvent
+	if err := json.Unmarshal([]byte(letter.Payload), &evt); err != nil {
+		return ""
+	}
+	return evt.EventID
+}
+
+// testBusContract checks the behavior the score consumer relies on from every event bus driver
+func testBusContract(t *testing.T, newBus busFactory) {
+	t.Run("delivers published events in order", func(t *testing.T) {
+		bus := newBus(t, newFakeDeadLetters(0), 0)
+		ctx := context.Background()
+		if err := bus.Publish(ctx, &entity.InteractionEvent{EventID: "e1", VideoID: "v1"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if err := bus.PublishBatch(ctx, []*entity.InteractionEvent{
+			{EventID: "e2", VideoID: "v1"}, {EventID: "e3", VideoID: "v1"},
+		}); err != nil {
+			t.Fatalf("PublishBatch: %v", err)
+		}
+
+		received := make(chan string, 3)
+		stop := subscribe(t, bus, func(ctx context.Context, evt *entity.InteractionEvent) error {
+			received <- evt.EventID
+			return nil
+		})
+		defer stop()
+
+		for _, want := range []string{"e1", "e2", "e3"} {
+			if got := wait(t, received, want); got != want {
+				t.Fatalf("received %s, want %s", got, want)
+			}
+		}
+	})
+
+	t.Run("redelivers an event until the handler succeeds", func(t *testing.T) {
+		store := newFakeDeadLetters(0)
+		bus := newBus(t, store, 3)
+		// Drivers may redeliver from another goroutine than the one that read the event first
+		var attempts atomic.Int32
+		handled := make(chan int32, 1)
+		stop := subscribe(t, bus, func(ctx context.Context, evt *entity.InteractionEvent) error {
+			attempt := attempts.Add(1)
+			if attempt < 3 {
+				return errHandler
+			}
+			handled <- attempt
+			return nil
+		})
+		defer stop()
+
+		if err := bus.Publish(context.Background(), &entity.InteractionEvent{EventID: "e1"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if got := wait(t, handled, "the third attempt"); got != 3 {
+			t.Fatalf("handled on attempt %d, want 3", got)
+		}
+		if _, letters := store.snapshot(); len(letters) != 0 {
+			t.Fatalf("dead lettered %d events, want none", len(letters))
+		}
+	})
+
+	t.Run("dead letters an event the handler keeps failing", func(t *testing.T) {
+		store := newFakeDeadLetters(0)
+		bus := newBus(t, store, 2)
+		stop := subscribe(t, bus, func(ctx context.Context, evt *entity.InteractionEvent) error {
+			return errHandler
+		})
+		defer stop()
+
+		if err := bus.Publish(context.Background(), &entity.InteractionEvent{EventID: "e1"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		wait(t, store.stored, "the dead letter")
+		_, letters := store.snapshot()
+		if len(letters) != 1 {
+			t.Fatalf("dead lettered %d events, want 1", len(letters))
+		}
+		if letters[0].Attempts != 3 || letters[0].Error != errHandler.Error() {
+			t.Fatalf("dead letter has %d attempts and error %q, want 3 and %q", letters[0].Attempts, letters[0].Error, errHandler)
+		}
+	})
+
+	t.Run("retries the dead letter store instead of dropping the event", func(t *testing.T) {
+		store := newFakeDeadLetters(2)
+		bus := newBus(t, store, 0)
+		stop := subscribe(t, bus, func(ctx context.Context, evt *entity.InteractionEvent) error {
+			return errHandler
+		})
+		defer stop()
+
+		if err := bus.Publish(context.Background(), &entity.InteractionEvent{EventID: "e1"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		wait(t, store.stored, "the dead letter")
+		tries, letters := store.snapshot()
+		if tries != 3 || len(letters) != 1 || payloadEventID(letters[0]) != "e1" {
+			t.Fatalf("stored %d dead letters in %d tries, want e1 stored on the third try", len(letters), tries)
+		}
+	})
+
+	t.Run("stops when its context is canceled", func(t *testing.T) {
+		bus := newBus(t, newFakeDeadLetters(0), 0)
+		stop := subscribe(t, bus, func(ctx context.Context, evt *entity.InteractionEvent) error {
+			return nil
+		})
+		if err := stop(); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Subscribe returned %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestMemoryBus(t *testing.T) {
+	testBusContract(t, func(t *testing.T, store event.DeadLetterStore, maxRetries int) event.EventBus {
+		return NewMemoryBus(store, MemoryOptions{
+			BufferSize: 16,
+			MaxRetries: maxRetries,
+			Backoff:    time.Millisecond,
+		})
+	})
+}
@@ -0,0 +1,134 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaOptions configures a KafkaBus
+type KafkaOptions struct {
+	Brokers    []string
+	Topic      string
+	GroupID    string
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// KafkaBus publishes and consumes interaction events through a Kafka topic.
+// It speaks the Kafka protocol only, so any compatible broker (e.g. Redpanda) works.
+type KafkaBus struct {
+	writer      *kafka.Writer
+	deadLetters event.DeadLetterStore
+	opts        KafkaOptions
+}
+
+// NewKafkaBus creates a new KafkaBus instance
+func NewKafkaBus(deadLetters event.DeadLetterStore, opts KafkaOptions) *KafkaBus {
+	return &KafkaBus{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(opts.Brokers...),
+			Topic:                  opts.Topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		deadLetters: deadLetters,
+		opts:        opts,
+	}
+}
+
+// Publish writes an interaction event to the topic, keyed by video so events of a video stay ordered
+func (b *KafkaBus) Publish(ctx context.Context, evt *entity.InteractionEvent) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		log.Printf("Failed to marshal event for topic %s: %v", b.opts.Topic, err)
+		return err
+	}
+
+	if err := b.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(evt.VideoID),
+		Value: data,
+	}); err != nil {
+		log.Printf("Failed to write event to topic %s: %v", b.opts.Topic, err)
+		return err
+	}
+	return nil
+}
+
+// PublishBatch writes several interaction events to the topic in one call
+func (b *KafkaBus) PublishBatch(ctx context.Context, evts []*entity.InteractionEvent) error {
+	messages := make([]kafka.Message, 0, len(evts))
+	for _, evt := range evts {
+		data, err := json.Marshal(evt)
+		if err != nil {
+			log.Printf("Failed to marshal event for topic %s: %v", b.opts.Topic, err)
+			return err
+		}
+		messages = append(messages, kafka.Message{
+			Key:   []byte(evt.VideoID),
+			Value: data,
+		})
+	}
+
+	if err := b.writer.WriteMessages(ctx, messages...); err != nil {
+		log.Printf("Failed to write %d events to topic %s: %v", len(evts), b.opts.Topic, err)
+		return err
+	}
+	return nil
+}
+
+// Subscribe reads the topic as a member of the consumer group and passes events to handler.
+// Offsets are committed once handler succeeds or the message is moved to the dead letter store.
+// Storing a dead letter is retried until it succeeds, as committing past it would lose the message.
+func (b *KafkaBus) Subscribe(ctx context.Context, handler event.Handler) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: b.opts.Brokers,
+		Topic:   b.opts.Topic,
+		GroupID: b.opts.GroupID,
+	})
+	defer reader.Close()
+
+	log.Printf("Started consumer in group %s on topic %s", b.opts.GroupID, b.opts.Topic)
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to fetch from topic %s: %v", b.opts.Topic, err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		messageID := strconv.Itoa(msg.Partition) + "-" + strconv.FormatInt(msg.Offset, 10)
+		var evt entity.InteractionEvent
+		if err := json.Unmarshal(msg.Value, &evt); err != nil {
+			log.Printf("Failed to unmarshal message %s on topic %s: %v", messageID, b.opts.Topic, err)
+			if err := deadLetterWithRetry(ctx, b.deadLetters, DriverKafka, messageID, msg.Value, err, 1, b.opts.Backoff); err != nil {
+				return err
+			}
+		} else if err := handleWithRetry(ctx, handler, &evt, b.opts.MaxRetries, b.opts.Backoff); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := deadLetterWithRetry(
+				ctx, b.deadLetters, DriverKafka, messageID, msg.Value, err, b.opts.MaxRetries+1, b.opts.Backoff,
+			); err != nil {
+				return err
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Failed to commit offset %d on topic %s: %v", msg.Offset, b.opts.Topic, err)
+		}
+	}
+}
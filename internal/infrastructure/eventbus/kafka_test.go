@@ -0,0 +1,32 @@
+package eventbus
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/usecase/event"
+)
+
+// TestKafkaBus runs the contract against the Kafka compatible broker at TEST_KAFKA_BROKERS, e.g.
+// localhost:9092 for a local Redpanda. The broker must allow topic auto-creation, every subtest
+// writes to its own topic.
+func TestKafkaBus(t *testing.T) {
+	brokers := os.Getenv("TEST_KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("TEST_KAFKA_BROKERS is not set")
+	}
+
+	testBusContract(t, func(t *testing.T, store event.DeadLetterStore, maxRetries int) event.EventBus {
+		topic := "test_interaction_events_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		return NewKafkaBus(store, KafkaOptions{
+			Brokers:    strings.Split(brokers, ","),
+			Topic:      topic,
+			GroupID:    topic,
+			MaxRetries: maxRetries,
+			Backoff:    time.Millisecond,
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+)
+
+// MemoryOptions configures a MemoryBus
+type MemoryOptions struct {
+	BufferSize int
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// MemoryBus delivers interaction events through an in-process channel.
+// It is meant for tests and single-binary deployments, events are lost when the process exits.
+type MemoryBus struct {
+	events      chan *entity.InteractionEvent
+	deadLetters event.DeadLetterStore
+	opts        MemoryOptions
+}
+
+// NewMemoryBus creates a new MemoryBus instance
+func NewMemoryBus(deadLetters event.DeadLetterStore, opts MemoryOptions) *MemoryBus {
+	return &MemoryBus{
+		events:      make(chan *entity.InteractionEvent, opts.BufferSize),
+		deadLetters: deadLetters,
+		opts:        opts,
+	}
+}
+
+// Publish queues an interaction event, blocking while the buffer is full
+func (b *MemoryBus) Publish(ctx context.Context, evt *entity.InteractionEvent) error {
+	select {
+	case b.events <- evt:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PublishBatch queues several interaction events in order
+func (b *MemoryBus) PublishBatch(ctx context.Context, evts []*entity.InteractionEvent) error {
+	for _, evt := range evts {
+		if err := b.Publish(ctx, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe passes queued events to handler until ctx is done.
+// Events still failing after MaxRetries are moved to the dead letter store, which is retried until it
+// stores them so they are not dropped.
+func (b *MemoryBus) Subscribe(ctx context.Context, handler event.Handler) error {
+	log.Println("Started in-memory consumer for interaction events")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case evt := <-b.events:
+			if err := handleWithRetry(ctx, handler, evt, b.opts.MaxRetries, b.opts.Backoff); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				payload, _ := json.Marshal(evt)
+				if err := deadLetterWithRetry(
+					ctx, b.deadLetters, DriverMemory, evt.EventID, payload, err, b.opts.MaxRetries+1, b.opts.Backoff,
+				); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// payloadField is the stream entry field holding the JSON encoded event
+const payloadField = "payload"
+
+// RedisOptions configures a RedisStream
+type RedisOptions struct {
+	Name          string
+	Group         string
+	Consumer      string
+	MaxLen        int64
+	BatchSize     int64
+	Block         time.Duration
+	ClaimMinIdle  time.Duration
+	ClaimInterval time.Duration
+	// MaxDeliveries is how many times an entry is delivered before it is moved to the dead letter store
+	MaxDeliveries int64
+	// Backoff is the first wait between in-place retries, used when a zero ClaimInterval disables reclaiming
+	Backoff time.Duration
+}
+
+// RedisStream publishes and consumes interaction events through a Redis Stream.
+// Entries stay pending in the consumer group until they are acknowledged, so
+// events published while no consumer is running are delivered once one starts.
+type RedisStream struct {
+	client      *redis.Client
+	deadLetters event.DeadLetterStore
+	opts        RedisOptions
+}
+
+// NewRedisStream creates a new RedisStream instance
+func NewRedisStream(client *redis.Client, deadLetters event.DeadLetterStore, opts RedisOptions) *RedisStream {
+	if opts.Consumer == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "consumer"
+		}
+		opts.Consumer = hostname
+	}
+	return &RedisStream{
+		client:      client,
+		deadLetters: deadLetters,
+		opts:        opts,
+	}
+}
+
+// Publish appends an interaction event to the stream, trimming it to roughly MaxLen entries
+func (s *RedisStream) Publish(ctx context.Context, evt *entity.InteractionEvent) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		log.Printf("Failed to marshal event for stream %s: %v", s.opts.Name, err)
+		return err
+	}
+
+	if err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.opts.Name,
+		MaxLen: s.opts.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{payloadField: data},
+	}).Err(); err != nil {
+		log.Printf("Failed to add event to stream %s: %v", s.opts.Name, err)
+		return err
+	}
+	return nil
+}
+
+// PublishBatch appends several interaction events to the stream in one pipeline
+func (s *RedisStream) PublishBatch(ctx context.Context, evts []*entity.InteractionEvent) error {
+	pipe := s.client.Pipeline()
+	for _, evt := range evts {
+		data, err := json.Marshal(evt)
+		if err != nil {
+			log.Printf("Failed to marshal event for stream %s: %v", s.opts.Name, err)
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: s.opts.Name,
+			MaxLen: s.opts.MaxLen,
+			Approx: true,
+			Values: map[string]interface{}{payloadField: data},
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to add %d events to stream %s: %v", len(evts), s.opts.Name, err)
+		return err
+	}
+	return nil
+}
+
+// Subscribe reads events from the stream as part of the consumer group and passes them to handler.
+// An entry is acknowledged only when handler succeeds; failed entries stay pending and are
+// reclaimed once they have been idle for ClaimMinIdle, until they reach MaxDeliveries and are
+// moved to the dead letter store. Without reclaiming, failed entries are retried in place instead.
+// Subscribe blocks until ctx is done.
+func (s *RedisStream) Subscribe(ctx context.Context, handler event.Handler) error {
+	if err := s.ensureGroup(ctx); err != nil {
+		return err
+	}
+
+	go s.reclaimLoop(ctx, handler)
+
+	log.Printf("Started consumer %s in group %s on stream %s", s.opts.Consumer, s.opts.Group, s.opts.Name)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.opts.Group,
+			Consumer: s.opts.Consumer,
+			Streams:  []string{s.opts.Name, ">"},
+			Count:    s.opts.BatchSize,
+			Block:    s.opts.Block,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to read from stream %s: %v", s.opts.Name, err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, st := range streams {
+			for _, msg := range st.Messages {
+				s.process(ctx, msg, handler)
+			}
+		}
+	}
+}
+
+// ensureGroup creates the consumer group (and the stream) if it does not exist yet.
+// The group starts at the beginning of the stream so no earlier entries are skipped.
+func (s *RedisStream) ensureGroup(ctx context.Context) error {
+	err := s.client.XGroupCreateMkStream(ctx, s.opts.Name, s.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Printf("Failed to create consumer group %s on stream %s: %v", s.opts.Group, s.opts.Name, err)
+		return err
+	}
+	return nil
+}
+
+// reclaimLoop periodically takes over entries left pending by crashed or stuck consumers,
+// a zero ClaimInterval disables it and failed entries are retried in place by process
+func (s *RedisStream) reclaimLoop(ctx context.Context, handler event.Handler) {
+	if s.opts.ClaimInterval <= 0 {
+		log.Printf("Reclaiming pending entries on stream %s is disabled", s.opts.Name)
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.ClaimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reclaim(ctx, handler)
+		}
+	}
+}
+
+// reclaim walks the pending entries list with XAUTOCLAIM and processes every claimed entry
+func (s *RedisStream) reclaim(ctx context.Context, handler event.Handler) {
+	start := "0-0"
+	for {
+		messages, next, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   s.opts.Name,
+			Group:    s.opts.Group,
+			Consumer: s.opts.Consumer,
+			MinIdle:  s.opts.ClaimMinIdle,
+			Start:    start,
+			Count:    s.opts.BatchSize,
+		}).Result()
+		if err != nil {
+			log.Printf("Failed to reclaim pending entries on stream %s: %v", s.opts.Name, err)
+			return
+		}
+
+		if len(messages) > 0 {
+			log.Printf("Reclaimed %d pending entries on stream %s", len(messages), s.opts.Name)
+		}
+		for _, msg := range messages {
+			s.process(ctx, msg, handler)
+		}
+
+		if next == "0-0" || next == "" {
+			return
+		}
+		start = next
+	}
+}
+
+// process decodes a stream entry, runs handler and acknowledges the entry on success
+func (s *RedisStream) process(ctx context.Context, msg redis.XMessage, handler event.Handler) {
+	var evt entity.InteractionEvent
+	payload, _ := msg.Values[payloadField].(string)
+	if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+		// A malformed entry will never succeed, move it aside so it does not block the group
+		log.Printf("Failed to unmarshal entry %s on stream %s: %v", msg.ID, s.opts.Name, err)
+		s.deadLetter(ctx, msg.ID, payload, err, 1)
+		return
+	}
+
+	if s.opts.ClaimInterval <= 0 {
+		// Nothing would deliver a pending entry again, so it is retried in place like the other drivers do
+		retries := max(int(s.opts.MaxDeliveries)-1, 0)
+		if err := handleWithRetry(ctx, handler, &evt, retries, s.opts.Backoff); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to handle entry %s on stream %s after %d attempts: %v", msg.ID, s.opts.Name, retries+1, err)
+			s.deadLetter(ctx, msg.ID, payload, err, retries+1)
+			return
+		}
+		s.ack(ctx, msg.ID)
+		return
+	}
+
+	if err := handler(ctx, &evt); err != nil {
+		deliveries := s.deliveries(ctx, msg.ID)
+		if deliveries >= s.opts.MaxDeliveries {
+			log.Printf("Failed to handle entry %s on stream %s after %d deliveries: %v", msg.ID, s.opts.Name, deliveries, err)
+			s.deadLetter(ctx, msg.ID, payload, err, int(deliveries))
+			return
+		}
+		log.Printf("Failed to handle entry %s on stream %s, leaving it pending: %v", msg.ID, s.opts.Name, err)
+		return
+	}
+	s.ack(ctx, msg.ID)
+}
+
+// deliveries returns how many times an entry has been delivered to the group
+func (s *RedisStream) deliveries(ctx context.Context, id string) int64 {
+	pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: s.opts.Name,
+		Group:  s.opts.Group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		if err != nil {
+			log.Printf("Failed to get pending entry %s on stream %s: %v", id, s.opts.Name, err)
+		}
+		return 0
+	}
+	return pending[0].RetryCount
+}
+
+// deadLetter stores an entry in the dead letter store and acknowledges it.
+// The entry stays pending if it could not be stored, to be reclaimed and dead-lettered again;
+// without reclaiming the store is retried until it succeeds or ctx is done.
+func (s *RedisStream) deadLetter(ctx context.Context, id string, payload string, cause error, attempts int) {
+	var err error
+	if s.opts.ClaimInterval <= 0 {
+		err = deadLetterWithRetry(ctx, s.deadLetters, DriverRedis, id, []byte(payload), cause, attempts, s.opts.Backoff)
+	} else {
+		err = deadLetter(ctx, s.deadLetters, DriverRedis, id, []byte(payload), cause, attempts)
+	}
+	if err != nil {
+		log.Printf("Failed to dead letter entry %s on stream %s: %v", id, s.opts.Name, err)
+		return
+	}
+	s.ack(ctx, id)
+}
+
+func (s *RedisStream) ack(ctx context.Context, id string) {
+	if err := s.client.XAck(ctx, s.opts.Name, s.opts.Group, id).Err(); err != nil {
+		log.Printf("Failed to ack entry %s on stream %s: %v", id, s.opts.Name, err)
+	}
+}
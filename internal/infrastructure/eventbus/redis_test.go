@@ -0,0 +1,50 @@
+package eventbus
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-server/internal/usecase/event"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TestRedisBus runs the contract against the Redis server at TEST_REDIS_ADDR, e.g. localhost:6379,
+// with reclaiming on and off. Every subtest uses its own stream, which is deleted afterwards.
+func TestRedisBus(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("Ping %s: %v", addr, err)
+	}
+
+	for name, claimInterval := range map[string]time.Duration{
+		"reclaiming":        20 * time.Millisecond,
+		"retrying in place": 0,
+	} {
+		t.Run(name, func(t *testing.T) {
+			testBusContract(t, func(t *testing.T, store event.DeadLetterStore, maxRetries int) event.EventBus {
+				stream := "test_interaction_events_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+				t.Cleanup(func() { client.Del(context.Background(), stream) })
+				return NewRedisStream(client, store, RedisOptions{
+					Name:          stream,
+					Group:         "test_score_service",
+					Consumer:      "test_consumer",
+					BatchSize:     10,
+					Block:         100 * time.Millisecond,
+					ClaimMinIdle:  10 * time.Millisecond,
+					ClaimInterval: claimInterval,
+					MaxDeliveries: int64(maxRetries + 1),
+					Backoff:       time.Millisecond,
+				})
+			})
+		})
+	}
+}
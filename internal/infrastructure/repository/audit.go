@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var AuditCollectionName = "interaction_audits"
+
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditRepository initializes the repository
+func NewAuditRepository(db *mongo.Database) *AuditRepository {
+	return &AuditRepository{
+		collection: db.Collection(AuditCollectionName),
+	}
+}
+
+// Insert stores a new audit record
+func (r *AuditRepository) Insert(ctx context.Context, audit *entity.InteractionAudit) error {
+	if _, err := r.collection.InsertOne(ctx, audit); err != nil {
+		log.Printf("Failed to insert audit for event %s: %v", audit.EventID, err)
+		return err
+	}
+	log.Printf("Audited event %s of user %s on video %s as %s: %s",
+		audit.EventID, audit.UserID, audit.VideoID, audit.Action, audit.Reason)
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var DeadLetterCollectionName = "dead_letters"
+
+type DeadLetterRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDeadLetterRepository initializes the repository
+func NewDeadLetterRepository(db *mongo.Database) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		collection: db.Collection(DeadLetterCollectionName),
+	}
+}
+
+// Insert stores a new dead letter
+func (r *DeadLetterRepository) Insert(ctx context.Context, letter *entity.DeadLetter) error {
+	if _, err := r.collection.InsertOne(ctx, letter); err != nil {
+		log.Printf("Failed to insert dead letter for message %s: %v", letter.MessageID, err)
+		return err
+	}
+	log.Printf("Stored dead letter for message %s: %s", letter.MessageID, letter.Error)
+	return nil
+}
+
+// List retrieves dead letters, newest first
+func (r *DeadLetterRepository) List(ctx context.Context, limit int64, offset int64) ([]*entity.DeadLetter, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		log.Printf("Failed to list dead letters: %v", err)
+		return nil, err
+	}
+
+	letters := []*entity.DeadLetter{}
+	if err := cursor.All(ctx, &letters); err != nil {
+		log.Printf("Failed to decode dead letters: %v", err)
+		return nil, err
+	}
+	return letters, nil
+}
+
+// GetByID retrieves a dead letter by its ID
+func (r *DeadLetterRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.DeadLetter, error) {
+	var letter entity.DeadLetter
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&letter); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to get dead letter %s: %v", id.Hex(), err)
+		}
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// Delete removes a dead letter by its ID
+func (r *DeadLetterRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Failed to delete dead letter %s: %v", id.Hex(), err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RedriveWithOutbox removes a dead letter and stores an outbox message carrying its event in a single
+// transaction, so the outbox relay of a running server publishes the event again.
+// It returns mongo.ErrNoDocuments if the dead letter does not exist.
+func (r *DeadLetterRepository) RedriveWithOutbox(ctx context.Context, id primitive.ObjectID, event *entity.InteractionEvent) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		log.Printf("Failed to start session: %v", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	now := time.Now()
+	message := &entity.OutboxMessage{
+		Event:         *event,
+		Status:        entity.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if _, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		result, err := r.collection.DeleteOne(sc, bson.M{"_id": id})
+		if err != nil {
+			return nil, err
+		}
+		if result.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		if _, err := r.collection.Database().Collection(OutboxCollectionName).InsertOne(sc, message); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to re-drive dead letter %s: %v", id.Hex(), err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	QuarantineCollectionName  = "quarantined_events"
+	FlaggedUserCollectionName = "flagged_users"
+)
+
+// FraudRepository keeps the fraud detection counters in Redis and the quarantine and flagged users in MongoDB
+type FraudRepository struct {
+	quarantine  *mongo.Collection
+	flagged     *mongo.Collection
+	redisClient *redis.Client
+}
+
+// NewFraudRepository initializes the repository
+func NewFraudRepository(db *mongo.Database, redisClient *redis.Client) *FraudRepository {
+	return &FraudRepository{
+		quarantine:  db.Collection(QuarantineCollectionName),
+		flagged:     db.Collection(FlaggedUserCollectionName),
+		redisClient: redisClient,
+	}
+}
+
+// EnsureIndexes creates the unique index that keeps one quarantine entry per event
+func (r *FraudRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.quarantine.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "event.event_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		log.Printf("Failed to create quarantine index: %v", err)
+		return err
+	}
+	return nil
+}
+
+// CountUserEvent records an event of a user in the window at falls in and returns how many distinct
+// events the user sent in it, so counting a redelivered event again does not change the count
+func (r *FraudRepository) CountUserEvent(
+	ctx context.Context, userID string, eventID string, at time.Time, window time.Duration,
+) (int64, error) {
+	bucket := strconv.FormatInt(at.Unix()/int64(window.Seconds()), 10)
+	key := constant.FraudRatePrefix + userID + "_" + bucket
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.SAdd(ctx, key, eventID)
+	pipe.Expire(ctx, key, window)
+	count := pipe.SCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to count event of user %s: %v", userID, err)
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// AddFingerprintUser records that a user interacted from a fingerprint in the window at falls in
+// and returns how many distinct users did so
+func (r *FraudRepository) AddFingerprintUser(
+	ctx context.Context, fingerprint string, userID string, at time.Time, window time.Duration,
+) (int64, error) {
+	bucket := strconv.FormatInt(at.Unix()/int64(window.Seconds()), 10)
+	key := constant.FraudFingerprintPrefix + fingerprint + "_" + bucket
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.SAdd(ctx, key, userID)
+	pipe.Expire(ctx, key, window)
+	users := pipe.SCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to record user %s on fingerprint %s: %v", userID, fingerprint, err)
+		return 0, err
+	}
+	return users.Val(), nil
+}
+
+// CountVideoView adds a view event to a video's HyperLogLog of views, counting a redelivered event once
+func (r *FraudRepository) CountVideoView(ctx context.Context, videoID string, eventID string) error {
+	if err := r.redisClient.PFAdd(ctx, constant.VideoViewCountPrefix+videoID, eventID).Err(); err != nil {
+		log.Printf("Failed to count view of video %s: %v", videoID, err)
+		return err
+	}
+	return nil
+}
+
+// GetVideoViews retrieves the approximate number of distinct view events counted for a video
+func (r *FraudRepository) GetVideoViews(ctx context.Context, videoID string) (int64, error) {
+	views, err := r.redisClient.PFCount(ctx, constant.VideoViewCountPrefix+videoID).Result()
+	if err != nil {
+		log.Printf("Failed to get views of video %s: %v", videoID, err)
+		return 0, err
+	}
+	return views, nil
+}
+
+// Quarantine holds an event, an event that is already in quarantine is left as is
+func (r *FraudRepository) Quarantine(ctx context.Context, quarantined *entity.QuarantinedEvent) error {
+	if _, err := r.quarantine.UpdateOne(ctx,
+		bson.M{"event.event_id": quarantined.Event.EventID},
+		bson.M{"$setOnInsert": quarantined},
+		options.Update().SetUpsert(true),
+	); err != nil {
+		log.Printf("Failed to quarantine event %s: %v", quarantined.Event.EventID, err)
+		return err
+	}
+	log.Printf("Quarantined event %s of user %s: %s", quarantined.Event.EventID, quarantined.Event.UserID, quarantined.Reason)
+	return nil
+}
+
+// IsReleased reports whether an admin released the quarantined event with this event ID
+func (r *FraudRepository) IsReleased(ctx context.Context, eventID string) (bool, error) {
+	count, err := r.quarantine.CountDocuments(ctx, bson.M{"event.event_id": eventID, "status": entity.QuarantineReleased})
+	if err != nil {
+		log.Printf("Failed to check release of event %s: %v", eventID, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteHeld removes the quarantine entry of an event if it is still held, reporting whether it was
+func (r *FraudRepository) DeleteHeld(ctx context.Context, eventID string) (bool, error) {
+	result, err := r.quarantine.DeleteOne(ctx, bson.M{"event.event_id": eventID, "status": entity.QuarantineHeld})
+	if err != nil {
+		log.Printf("Failed to delete quarantined event %s: %v", eventID, err)
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// ListQuarantined retrieves quarantined events with the given status, or all if empty, newest first
+func (r *FraudRepository) ListQuarantined(
+	ctx context.Context, status entity.QuarantineStatus, limit int64, offset int64,
+) ([]*entity.QuarantinedEvent, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.quarantine.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Failed to list quarantined events: %v", err)
+		return nil, err
+	}
+
+	events := []*entity.QuarantinedEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		log.Printf("Failed to decode quarantined events: %v", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetHeldEventIDs retrieves the event IDs of all events still held in quarantine
+func (r *FraudRepository) GetHeldEventIDs(ctx context.Context) ([]string, error) {
+	values, err := r.quarantine.Distinct(ctx, "event.event_id", bson.M{"status": entity.QuarantineHeld})
+	if err != nil {
+		log.Printf("Failed to get held event IDs: %v", err)
+		return nil, err
+	}
+	eventIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if eventID, ok := value.(string); ok {
+			eventIDs = append(eventIDs, eventID)
+		}
+	}
+	return eventIDs, nil
+}
+
+// GetQuarantined retrieves a quarantined event by its ID
+func (r *FraudRepository) GetQuarantined(ctx context.Context, id primitive.ObjectID) (*entity.QuarantinedEvent, error) {
+	var quarantined entity.QuarantinedEvent
+	if err := r.quarantine.FindOne(ctx, bson.M{"_id": id}).Decode(&quarantined); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to get quarantined event %s: %v", id.Hex(), err)
+		}
+		return nil, err
+	}
+	return &quarantined, nil
+}
+
+// MarkReleased marks a quarantined event as released so the score consumer applies it
+func (r *FraudRepository) MarkReleased(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.quarantine.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"status": entity.QuarantineReleased, "released_at": time.Now()},
+	}); err != nil {
+		log.Printf("Failed to release quarantined event %s: %v", id.Hex(), err)
+		return err
+	}
+	return nil
+}
+
+// DeleteQuarantined removes a quarantined event by its ID
+func (r *FraudRepository) DeleteQuarantined(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.quarantine.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Failed to delete quarantined event %s: %v", id.Hex(), err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FlagUser marks a user as suspicious, keeping the first reason if already flagged
+func (r *FraudRepository) FlagUser(ctx context.Context, userID string, reason string) error {
+	if _, err := r.flagged.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$setOnInsert": bson.M{"reason": reason, "flagged_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	); err != nil {
+		log.Printf("Failed to flag user %s: %v", userID, err)
+		return err
+	}
+	log.Printf("Flagged user %s: %s", userID, reason)
+	return nil
+}
+
+// GetFlaggedUser retrieves a flagged user, or nil if the user is not flagged
+func (r *FraudRepository) GetFlaggedUser(ctx context.Context, userID string) (*entity.FlaggedUser, error) {
+	var user entity.FlaggedUser
+	if err := r.flagged.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Failed to get flagged user %s: %v", userID, err)
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListFlaggedUsers retrieves flagged users, most recently flagged first
+func (r *FraudRepository) ListFlaggedUsers(ctx context.Context, limit int64, offset int64) ([]*entity.FlaggedUser, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "flagged_at", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.flagged.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		log.Printf("Failed to list flagged users: %v", err)
+		return nil, err
+	}
+
+	users := []*entity.FlaggedUser{}
+	if err := cursor.All(ctx, &users); err != nil {
+		log.Printf("Failed to decode flagged users: %v", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// UnflagUser removes the flag of a user
+func (r *FraudRepository) UnflagUser(ctx context.Context, userID string) error {
+	result, err := r.flagged.DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		log.Printf("Failed to unflag user %s: %v", userID, err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
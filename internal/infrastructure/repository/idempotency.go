@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/common/constant"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type IdempotencyRepository struct {
+	redisClient *redis.Client
+}
+
+// NewIdempotencyRepository initializes the repository
+func NewIdempotencyRepository(redisClient *redis.Client) *IdempotencyRepository {
+	return &IdempotencyRepository{redisClient: redisClient}
+}
+
+// Reserve marks an idempotency key as processing if it has not been seen before.
+// When the key already exists, it returns false along with the stored status.
+func (r *IdempotencyRepository) Reserve(
+	ctx context.Context, key string, ttl time.Duration,
+) (bool, constant.IdempotencyStatus, error) {
+	redisKey := constant.IdempotencyKeyPrefix + key
+	reserved, err := r.redisClient.SetNX(ctx, redisKey, string(constant.IdempotencyProcessing), ttl).Result()
+	if err != nil {
+		log.Printf("Failed to reserve idempotency key %s: %v", key, err)
+		return false, "", err
+	}
+	if reserved {
+		return true, constant.IdempotencyProcessing, nil
+	}
+
+	status, err := r.redisClient.Get(ctx, redisKey).Result()
+	if err == redis.Nil {
+		// The key expired between SETNX and GET, try once more
+		return r.Reserve(ctx, key, ttl)
+	}
+	if err != nil {
+		log.Printf("Failed to get idempotency key %s: %v", key, err)
+		return false, "", err
+	}
+	return false, constant.IdempotencyStatus(status), nil
+}
+
+// Complete marks an idempotency key as completed and keeps it for ttl
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	if err := r.redisClient.Set(ctx, constant.IdempotencyKeyPrefix+key, string(constant.IdempotencyCompleted), ttl).Err(); err != nil {
+		log.Printf("Failed to complete idempotency key %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+// Release removes an idempotency key so a failed request can be retried
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if err := r.redisClient.Del(ctx, constant.IdempotencyKeyPrefix+key).Err(); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", key, err)
+		return err
+	}
+	return nil
+}
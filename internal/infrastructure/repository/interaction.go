@@ -4,8 +4,11 @@ import (
 	"context"
 	"go-server/internal/entity"
 	"log"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var InteractionCollectionName = "interactions"
@@ -19,12 +22,204 @@ func NewInteractionRepository(mg *mongo.Database) *InteractionRepository {
 	return &InteractionRepository{dbMongo: mg}
 }
 
-// Insert Interaction inserts a new interaction into the database
-func (repo *InteractionRepository) InsertOne(ctx context.Context, interactionData *entity.Interaction) error {
-	if _, err := repo.dbMongo.Collection(InteractionCollectionName).InsertOne(ctx, interactionData); err != nil {
-		log.Printf("Error inserting interaction: %v", err)
+// EnsureIndexes creates the unique index on the event ID, which retractions look interactions up by
+// and which keeps an event from being stored twice. Interactions stored before event IDs existed are left out.
+func (repo *InteractionRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := repo.dbMongo.Collection(InteractionCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "event_id", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"event_id": bson.M{"$gt": ""}}),
+	}); err != nil {
+		log.Printf("Failed to create interaction event index: %v", err)
 		return err
 	}
+	return nil
+}
+
+// InsertWithOutbox inserts a new interaction and its outbox message in a single transaction,
+// so either both are stored or neither is. Transactions require MongoDB to run as a replica set.
+func (repo *InteractionRepository) InsertWithOutbox(
+	ctx context.Context, interactionData *entity.Interaction, message *entity.OutboxMessage,
+) error {
+	session, err := repo.dbMongo.Client().StartSession()
+	if err != nil {
+		log.Printf("Error starting session: %v", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	if _, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := repo.dbMongo.Collection(InteractionCollectionName).InsertOne(sc, interactionData); err != nil {
+			return nil, err
+		}
+		if _, err := repo.dbMongo.Collection(OutboxCollectionName).InsertOne(sc, message); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}); err != nil {
+		log.Printf("Error inserting interaction with outbox message: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// InsertManyWithOutbox inserts several interactions and their outbox messages in a single transaction
+func (repo *InteractionRepository) InsertManyWithOutbox(
+	ctx context.Context, interactions []*entity.Interaction, messages []*entity.OutboxMessage,
+) error {
+	session, err := repo.dbMongo.Client().StartSession()
+	if err != nil {
+		log.Printf("Error starting session: %v", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	interactionDocs := make([]interface{}, 0, len(interactions))
+	for _, interaction := range interactions {
+		interactionDocs = append(interactionDocs, interaction)
+	}
+	messageDocs := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		messageDocs = append(messageDocs, message)
+	}
+
+	if _, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := repo.dbMongo.Collection(InteractionCollectionName).InsertMany(sc, interactionDocs); err != nil {
+			return nil, err
+		}
+		if _, err := repo.dbMongo.Collection(OutboxCollectionName).InsertMany(sc, messageDocs); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}); err != nil {
+		log.Printf("Error inserting %d interactions with outbox messages: %v", len(interactions), err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByEventID retrieves an interaction by its event ID
+func (repo *InteractionRepository) GetByEventID(ctx context.Context, eventID string) (*entity.Interaction, error) {
+	var interaction entity.Interaction
+	if err := repo.dbMongo.Collection(InteractionCollectionName).FindOne(ctx, bson.M{"event_id": eventID}).Decode(&interaction); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error getting interaction %s: %v", eventID, err)
+		}
+		return nil, err
+	}
+	return &interaction, nil
+}
 
+// GetStoredEventIDs returns which of eventIDs belong to stored interactions
+func (repo *InteractionRepository) GetStoredEventIDs(ctx context.Context, eventIDs []string) (map[string]bool, error) {
+	stored := make(map[string]bool)
+	if len(eventIDs) == 0 {
+		return stored, nil
+	}
+	cursor, err := repo.dbMongo.Collection(InteractionCollectionName).Find(ctx,
+		bson.M{"event_id": bson.M{"$in": eventIDs}},
+		options.Find().SetProjection(bson.M{"event_id": 1}),
+	)
+	if err != nil {
+		log.Printf("Error finding stored interactions: %v", err)
+		return nil, err
+	}
+	var interactions []entity.Interaction
+	if err := cursor.All(ctx, &interactions); err != nil {
+		log.Printf("Error decoding stored interactions: %v", err)
+		return nil, err
+	}
+	for _, interaction := range interactions {
+		stored[interaction.EventID] = true
+	}
+	return stored, nil
+}
+
+// RetractWithOutbox marks an interaction as retracted and stores the outbox message of its retraction
+// in a single transaction. It returns mongo.ErrNoDocuments if the interaction was already retracted.
+func (repo *InteractionRepository) RetractWithOutbox(
+	ctx context.Context, eventID string, retractedAt time.Time, message *entity.OutboxMessage,
+) error {
+	session, err := repo.dbMongo.Client().StartSession()
+	if err != nil {
+		log.Printf("Error starting session: %v", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	if _, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		result, err := repo.dbMongo.Collection(InteractionCollectionName).UpdateOne(sc,
+			bson.M{"event_id": eventID, "retracted_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"retracted_at": retractedAt}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		if _, err := repo.dbMongo.Collection(OutboxCollectionName).InsertOne(sc, message); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error retracting interaction %s: %v", eventID, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ScanInteractions reads the interactions created within [from, to) in batches of batchSize and passes
+// each batch to fn, stopping at the first error. Nil bounds are open.
+func (repo *InteractionRepository) ScanInteractions(
+	ctx context.Context, from *time.Time, to *time.Time, batchSize int, fn func([]*entity.Interaction) error,
+) error {
+	createdAt := bson.M{}
+	if from != nil {
+		createdAt["$gte"] = *from
+	}
+	if to != nil {
+		createdAt["$lt"] = *to
+	}
+	filter := bson.M{}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	cursor, err := repo.dbMongo.Collection(InteractionCollectionName).Find(ctx, filter, options.Find().SetBatchSize(int32(batchSize)))
+	if err != nil {
+		log.Printf("Error reading interactions: %v", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := make([]*entity.Interaction, 0, batchSize)
+	for cursor.Next(ctx) {
+		var interaction entity.Interaction
+		if err := cursor.Decode(&interaction); err != nil {
+			log.Printf("Error decoding interaction: %v", err)
+			return err
+		}
+		batch = append(batch, &interaction)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]*entity.Interaction, 0, batchSize)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Error reading interactions: %v", err)
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
 	return nil
 }
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var OutboxCollectionName = "outbox"
+
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+// NewOutboxRepository initializes the repository
+func NewOutboxRepository(db *mongo.Database) *OutboxRepository {
+	return &OutboxRepository{
+		collection: db.Collection(OutboxCollectionName),
+	}
+}
+
+// EnsureIndexes creates the indexes used to find due and claimed messages and a TTL index expiring sent ones
+func (r *OutboxRepository) EnsureIndexes(ctx context.Context, sentRetention time.Duration) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "lease_token", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "sent_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(sentRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to create outbox indexes: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ClaimBatch locks up to limit of the oldest due pending messages for lease under token and returns them.
+// The lease pushes next_attempt_at forward so other relays skip the messages meanwhile, and a message
+// claimed by another relay between the lookup and the update keeps that relay's token.
+func (r *OutboxRepository) ClaimBatch(ctx context.Context, token string, limit int, lease time.Duration) ([]*entity.OutboxMessage, error) {
+	now := time.Now()
+	due := bson.M{
+		"status":          entity.OutboxPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := r.collection.Find(ctx, due, opts)
+	if err != nil {
+		log.Printf("Failed to find due outbox messages: %v", err)
+		return nil, err
+	}
+	var candidates []entity.OutboxMessage
+	if err := cursor.All(ctx, &candidates); err != nil {
+		log.Printf("Failed to decode due outbox messages: %v", err)
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.ID)
+	}
+	due["_id"] = bson.M{"$in": ids}
+	update := bson.M{
+		"$set": bson.M{
+			"next_attempt_at": now.Add(lease),
+			"lease_token":     token,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	if _, err := r.collection.UpdateMany(ctx, due, update); err != nil {
+		log.Printf("Failed to claim outbox messages: %v", err)
+		return nil, err
+	}
+
+	cursor, err = r.collection.Find(ctx, bson.M{"lease_token": token}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		log.Printf("Failed to find claimed outbox messages: %v", err)
+		return nil, err
+	}
+	var messages []*entity.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		log.Printf("Failed to decode claimed outbox messages: %v", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkSent marks an outbox message as published
+func (r *OutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":  entity.OutboxSent,
+			"sent_at": time.Now(),
+		},
+		"$unset": bson.M{"last_error": "", "lease_token": ""},
+	}
+	if _, err := r.collection.UpdateByID(ctx, id, update); err != nil {
+		log.Printf("Failed to mark outbox message %s as sent: %v", id.Hex(), err)
+		return err
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish and schedules the next attempt
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, reason string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      reason,
+		},
+		"$unset": bson.M{"lease_token": ""},
+	}
+	if _, err := r.collection.UpdateByID(ctx, id, update); err != nil {
+		log.Printf("Failed to mark outbox message %s as failed: %v", id.Hex(), err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// recomputedSuffix names the collections a recompute writes fresh scores to
+	recomputedSuffix = "_recomputed"
+	// previousSuffix names the backups of the score collections replaced by a swap
+	previousSuffix = "_previous"
+	// diffTolerance is the largest difference between two scores still considered equal
+	diffTolerance = 1e-9
+	// recomputeRunCollectionName holds the finished recompute waiting to be swapped in
+	recomputeRunCollectionName = "score_recomputes"
+	// recomputeRunID is the ID of the single recompute run document
+	recomputeRunID = "latest"
+)
+
+// PrepareRecompute forgets the previous run, drops the recomputed collections it left and
+// recreates the score ones with the same unique indexes as the score collections
+func (r *ScoreRepository) PrepareRecompute(ctx context.Context) error {
+	if _, err := r.db.Collection(recomputeRunCollectionName).DeleteOne(ctx, bson.M{"_id": recomputeRunID}); err != nil {
+		log.Printf("Failed to delete previous recompute run: %v", err)
+		return err
+	}
+	ledger := r.db.Collection(r.ledgerCollection.Name() + recomputedSuffix)
+	if err := ledger.Drop(ctx); err != nil {
+		log.Printf("Failed to drop %s: %v", ledger.Name(), err)
+		return err
+	}
+
+	indexes := map[*mongo.Collection]bson.D{
+		r.collection:         {{Key: "video_id", Value: 1}},
+		r.personalCollection: {{Key: "user_id", Value: 1}, {Key: "video_id", Value: 1}},
+	}
+	for collection, keys := range indexes {
+		fresh := r.db.Collection(collection.Name() + recomputedSuffix)
+		if err := fresh.Drop(ctx); err != nil {
+			log.Printf("Failed to drop %s: %v", fresh.Name(), err)
+			return err
+		}
+		if _, err := fresh.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    keys,
+			Options: options.Index().SetUnique(true),
+		}); err != nil {
+			log.Printf("Failed to create %s indexes: %v", fresh.Name(), err)
+			return err
+		}
+	}
+	return nil
+}
+
+// AddRecomputedScores increments the recomputed video scores and the recomputed personal scores,
+// keyed by user then video, upserting missing documents, and records the recomputed delta of each event
+func (r *ScoreRepository) AddRecomputedScores(
+	ctx context.Context, videos map[string]float64, personal map[string]map[string]float64, events map[string]float64,
+) error {
+	if len(videos) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(videos))
+	for videoID, score := range videos {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"video_id": videoID}).
+			SetUpdate(bson.M{"$inc": bson.M{"score": score}}).
+			SetUpsert(true))
+	}
+	fresh := r.db.Collection(r.collection.Name() + recomputedSuffix)
+	if _, err := fresh.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		log.Printf("Failed to write recomputed video scores: %v", err)
+		return err
+	}
+
+	models = models[:0]
+	for userID, scores := range personal {
+		for videoID, score := range scores {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"user_id": userID, "video_id": videoID}).
+				SetUpdate(bson.M{"$inc": bson.M{"score": score}}).
+				SetUpsert(true))
+		}
+	}
+	fresh = r.db.Collection(r.personalCollection.Name() + recomputedSuffix)
+	if _, err := fresh.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		log.Printf("Failed to write recomputed personal scores: %v", err)
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+	models = models[:0]
+	for eventID, delta := range events {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": eventID}).
+			SetUpdate(bson.M{"$set": bson.M{"delta": delta}}).
+			SetUpsert(true))
+	}
+	fresh = r.db.Collection(r.ledgerCollection.Name() + recomputedSuffix)
+	if _, err := fresh.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		log.Printf("Failed to write recomputed event deltas: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SaveRecomputeRun records the finished recompute whose scores can now be swapped in
+func (r *ScoreRepository) SaveRecomputeRun(ctx context.Context, run *entity.RecomputeRun) error {
+	if _, err := r.db.Collection(recomputeRunCollectionName).ReplaceOne(ctx,
+		bson.M{"_id": recomputeRunID}, run, options.Replace().SetUpsert(true)); err != nil {
+		log.Printf("Failed to save recompute run: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRecomputeRun retrieves the finished recompute waiting to be swapped in, or nil if there is none
+func (r *ScoreRepository) GetRecomputeRun(ctx context.Context) (*entity.RecomputeRun, error) {
+	var run entity.RecomputeRun
+	err := r.db.Collection(recomputeRunCollectionName).FindOne(ctx, bson.M{"_id": recomputeRunID}).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed to get recompute run: %v", err)
+		return nil, err
+	}
+	return &run, nil
+}
+
+// DiffRecomputedScores compares the recomputed video and personal scores with the current ones,
+// keeping the top largest differences of each
+func (r *ScoreRepository) DiffRecomputedScores(
+	ctx context.Context, top int64,
+) (*entity.ScoreDiffSummary, *entity.ScoreDiffSummary, error) {
+	videos, err := r.diffScores(ctx, r.collection, bson.M{"video_id": "$video_id"}, top)
+	if err != nil {
+		return nil, nil, err
+	}
+	personal, err := r.diffScores(ctx, r.personalCollection, bson.M{"user_id": "$user_id", "video_id": "$video_id"}, top)
+	if err != nil {
+		return nil, nil, err
+	}
+	return videos, personal, nil
+}
+
+// diffScores joins a score collection with its recomputed counterpart on key in one aggregation,
+// then summarizes the differences and keeps the top largest
+func (r *ScoreRepository) diffScores(
+	ctx context.Context, current *mongo.Collection, key bson.M, top int64,
+) (*entity.ScoreDiffSummary, error) {
+	fresh := r.db.Collection(current.Name() + recomputedSuffix)
+	pipeline := mongo.Pipeline{
+		{{Key: "$project", Value: bson.M{"_id": 0, "key": key, "recomputed": "$score", "in_recomputed": bson.M{"$literal": 1}}}},
+		{{Key: "$unionWith", Value: bson.M{"coll": current.Name(), "pipeline": bson.A{
+			bson.M{"$project": bson.M{"_id": 0, "key": key, "current": "$score", "in_current": bson.M{"$literal": 1}}},
+		}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           "$key",
+			"current":       bson.M{"$sum": "$current"},
+			"recomputed":    bson.M{"$sum": "$recomputed"},
+			"in_current":    bson.M{"$max": bson.M{"$ifNull": bson.A{"$in_current", 0}}},
+			"in_recomputed": bson.M{"$max": bson.M{"$ifNull": bson.A{"$in_recomputed", 0}}},
+		}}},
+		{{Key: "$set", Value: bson.M{"difference": bson.M{"$subtract": bson.A{"$recomputed", "$current"}}}}},
+		{{Key: "$set", Value: bson.M{"changed": bson.M{"$gt": bson.A{bson.M{"$abs": "$difference"}, diffTolerance}}}}},
+		{{Key: "$facet", Value: bson.M{
+			"summary": bson.A{bson.M{"$group": bson.M{
+				"_id":              nil,
+				"current":          bson.M{"$sum": "$in_current"},
+				"recomputed":       bson.M{"$sum": "$in_recomputed"},
+				"added":            bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$in_current", 0}}, 1, 0}}},
+				"removed":          bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$in_recomputed", 0}}, 1, 0}}},
+				"changed":          bson.M{"$sum": bson.M{"$cond": bson.A{"$changed", 1, 0}}},
+				"current_total":    bson.M{"$sum": "$current"},
+				"recomputed_total": bson.M{"$sum": "$recomputed"},
+			}}},
+			"largest": bson.A{
+				bson.M{"$match": bson.M{"changed": true}},
+				bson.M{"$set": bson.M{"abs": bson.M{"$abs": "$difference"}}},
+				bson.M{"$sort": bson.D{{Key: "abs", Value: -1}}},
+				bson.M{"$limit": top},
+				bson.M{"$project": bson.M{
+					"_id": 0, "user_id": "$_id.user_id", "video_id": "$_id.video_id",
+					"current": 1, "recomputed": 1, "difference": 1,
+				}},
+			},
+		}}},
+	}
+	cursor, err := fresh.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Printf("Failed to diff %s: %v", current.Name(), err)
+		return nil, err
+	}
+	var results []struct {
+		Summary []*entity.ScoreDiffSummary `bson:"summary"`
+		Largest []*entity.ScoreDiff        `bson:"largest"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		log.Printf("Failed to decode %s diff: %v", current.Name(), err)
+		return nil, err
+	}
+
+	summary := &entity.ScoreDiffSummary{}
+	if len(results) > 0 && len(results[0].Summary) > 0 {
+		summary = results[0].Summary[0]
+	}
+	summary.Largest = []*entity.ScoreDiff{}
+	if len(results) > 0 {
+		summary.Largest = append(summary.Largest, results[0].Largest...)
+	}
+	return summary, nil
+}
+
+// HasRecomputedScores reports whether both recomputed score collections exist
+func (r *ScoreRepository) HasRecomputedScores(ctx context.Context) (bool, error) {
+	names, err := r.db.ListCollectionNames(ctx, bson.M{"name": bson.M{"$in": bson.A{
+		r.collection.Name() + recomputedSuffix, r.personalCollection.Name() + recomputedSuffix,
+	}}})
+	if err != nil {
+		log.Printf("Failed to list recomputed score collections: %v", err)
+		return false, err
+	}
+	return len(names) == 2, nil
+}
+
+// SwapRecomputedScores rewrites the delta of every processed event ledger entry recorded before the
+// recompute started its scan to its recomputed delta, 0 for events the recompute skipped, so retractions
+// and subtractions take out what the new scores hold. Newer entries are left unchanged, as the recompute
+// may not have seen their events. It then backs up each score collection to its previous collection and renames the
+// recomputed collection over it. Each rename is atomic, but scores applied between the recompute
+// and the swap are lost. The ledger rewrite comes first as it is safe to repeat if the swap fails.
+func (r *ScoreRepository) SwapRecomputedScores(ctx context.Context, scannedFrom time.Time) error {
+	fresh := r.ledgerCollection.Name() + recomputedSuffix
+	rewrite, err := r.ledgerCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"processed_at": bson.M{"$lt": scannedFrom}}}},
+		{{Key: "$lookup", Value: bson.M{"from": fresh, "localField": "_id", "foreignField": "_id", "as": "recomputed"}}},
+		{{Key: "$project", Value: bson.M{"delta": bson.M{"$ifNull": bson.A{bson.M{"$first": "$recomputed.delta"}, 0}}}}},
+		{{Key: "$merge", Value: bson.M{
+			"into": r.ledgerCollection.Name(), "on": "_id", "whenMatched": "merge", "whenNotMatched": "discard",
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Printf("Failed to rewrite %s deltas: %v", r.ledgerCollection.Name(), err)
+		return err
+	}
+	rewrite.Close(ctx)
+	log.Printf("Rewrote the deltas of %s to the recomputed ones", r.ledgerCollection.Name())
+
+	for _, current := range []*mongo.Collection{r.collection, r.personalCollection} {
+		backup, err := current.Aggregate(ctx, mongo.Pipeline{{{Key: "$out", Value: current.Name() + previousSuffix}}})
+		if err != nil {
+			log.Printf("Failed to back up %s: %v", current.Name(), err)
+			return err
+		}
+		backup.Close(ctx)
+
+		if err := r.db.Client().Database("admin").RunCommand(ctx, bson.D{
+			{Key: "renameCollection", Value: r.db.Name() + "." + current.Name() + recomputedSuffix},
+			{Key: "to", Value: r.db.Name() + "." + current.Name()},
+			{Key: "dropTarget", Value: true},
+		}).Err(); err != nil {
+			log.Printf("Failed to swap %s: %v", current.Name(), err)
+			return err
+		}
+		log.Printf("Swapped recomputed scores into %s, previous scores are in %s", current.Name(), current.Name()+previousSuffix)
+	}
+
+	if err := r.db.Collection(fresh).Drop(ctx); err != nil {
+		log.Printf("Failed to drop %s: %v", fresh, err)
+		return err
+	}
+	if _, err := r.db.Collection(recomputeRunCollectionName).DeleteOne(ctx, bson.M{"_id": recomputeRunID}); err != nil {
+		log.Printf("Failed to delete swapped recompute run: %v", err)
+		return err
+	}
+	return nil
+}
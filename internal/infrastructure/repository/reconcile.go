@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// renewLeaseScript extends the lease only if it is still held by the given owner.
+// KEYS: lease. ARGV: owner, TTL (milliseconds).
+var renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes the lease only if it is still held by the given owner.
+// KEYS: lease. ARGV: owner.
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// repairCachedScoreScript sets a member's score only if its current score is still the expected one,
+// so an event applied since it was read is not overwritten. An empty expected score means absent,
+// an empty new score removes the member.
+// KEYS: ranking. ARGV: member, expected score, new score.
+var repairCachedScoreScript = redis.NewScript(`
+local current = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if ARGV[2] == '' then
+	if current then
+		return 0
+	end
+elseif not current or tonumber(current) ~= tonumber(ARGV[2]) then
+	return 0
+end
+if ARGV[3] == '' then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+else
+	redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+end
+return 1
+`)
+
+// ReconcileRepository compares the video scores in MongoDB with the global ranking in Redis
+// and keeps the lease and counters of the reconciler
+type ReconcileRepository struct {
+	collection       *mongo.Collection
+	ledgerCollection *mongo.Collection
+	redisClient      *redis.Client
+}
+
+// NewReconcileRepository initializes the repository
+func NewReconcileRepository(db *mongo.Database, redisClient *redis.Client) *ReconcileRepository {
+	return &ReconcileRepository{
+		collection:       db.Collection("video_scores"),
+		ledgerCollection: db.Collection("processed_events"),
+		redisClient:      redisClient,
+	}
+}
+
+// AcquireLease takes the reconciler lease for owner if no one holds it
+func (r *ReconcileRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := r.redisClient.SetNX(ctx, constant.ReconcileLease, owner, ttl).Result()
+	if err != nil {
+		log.Printf("Failed to acquire reconcile lease: %v", err)
+		return false, err
+	}
+	return acquired, nil
+}
+
+// RenewLease extends the lease held by owner, reporting false if it was lost
+func (r *ReconcileRepository) RenewLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	renewed, err := renewLeaseScript.Run(ctx, r.redisClient, []string{constant.ReconcileLease}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		log.Printf("Failed to renew reconcile lease: %v", err)
+		return false, err
+	}
+	return renewed == 1, nil
+}
+
+// ListScores retrieves up to limit video scores ordered by video ID, starting after afterVideoID
+func (r *ReconcileRepository) ListScores(ctx context.Context, afterVideoID string, limit int64) (map[string]float64, string, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "video_id", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"video_id": 1, "score": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"video_id": bson.M{"$gt": afterVideoID}}, opts)
+	if err != nil {
+		log.Printf("Failed to list video scores: %v", err)
+		return nil, "", err
+	}
+
+	var results []struct {
+		VideoID string  `bson:"video_id"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		log.Printf("Failed to decode video scores: %v", err)
+		return nil, "", err
+	}
+	scores := make(map[string]float64, len(results))
+	for _, result := range results {
+		scores[result.VideoID] = result.Score
+	}
+	last := ""
+	if len(results) > 0 {
+		last = results[len(results)-1].VideoID
+	}
+	return scores, last, nil
+}
+
+// GetScores retrieves the scores of several videos, videos without a score document are left out
+func (r *ReconcileRepository) GetScores(ctx context.Context, videoIDs []string) (map[string]float64, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"video_id": bson.M{"$in": videoIDs}},
+		options.Find().SetProjection(bson.M{"video_id": 1, "score": 1}))
+	if err != nil {
+		log.Printf("Failed to get video scores: %v", err)
+		return nil, err
+	}
+
+	var results []struct {
+		VideoID string  `bson:"video_id"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		log.Printf("Failed to decode video scores: %v", err)
+		return nil, err
+	}
+	scores := make(map[string]float64, len(results))
+	for _, result := range results {
+		scores[result.VideoID] = result.Score
+	}
+	return scores, nil
+}
+
+// GetCachedScores retrieves the global ranking scores of several videos in one pipeline,
+// videos missing from the ranking are left out
+func (r *ReconcileRepository) GetCachedScores(ctx context.Context, videoIDs []string) (map[string]float64, error) {
+	pipe := r.redisClient.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		cmds[i] = pipe.ZScore(ctx, constant.VideoRanking, videoID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("Failed to get cached scores for %d videos: %v", len(videoIDs), err)
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(videoIDs))
+	for i, videoID := range videoIDs {
+		if score, err := cmds[i].Result(); err == nil {
+			scores[videoID] = score
+		}
+	}
+	return scores, nil
+}
+
+// GetPendingVideos returns which of videoIDs have an event applied to the scores since the given time
+// whose processed marker is missing from Redis, i.e. whose ranking update may still be redelivered
+func (r *ReconcileRepository) GetPendingVideos(ctx context.Context, videoIDs []string, since time.Time) (map[string]bool, error) {
+	cursor, err := r.ledgerCollection.Find(ctx, bson.M{
+		"video_id":      bson.M{"$in": videoIDs},
+		"processed_at":  bson.M{"$gte": since},
+		"subtracted_at": bson.M{"$exists": false},
+	}, options.Find().SetProjection(bson.M{"_id": 1, "video_id": 1}))
+	if err != nil {
+		log.Printf("Failed to get recent events of %d videos: %v", len(videoIDs), err)
+		return nil, err
+	}
+	var events []*entity.ProcessedEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		log.Printf("Failed to decode recent events: %v", err)
+		return nil, err
+	}
+
+	pending := map[string]bool{}
+	if len(events) == 0 {
+		return pending, nil
+	}
+	pipe := r.redisClient.Pipeline()
+	cmds := make([]*redis.IntCmd, len(events))
+	for i, event := range events {
+		cmds[i] = pipe.Exists(ctx, constant.ProcessedEventPrefix+event.EventID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to check processed markers of %d events: %v", len(events), err)
+		return nil, err
+	}
+	for i, event := range events {
+		if cmds[i].Val() == 0 {
+			pending[event.VideoID] = true
+		}
+	}
+	return pending, nil
+}
+
+// ScanRanking iterates the global ranking with ZSCAN, returning a batch of members with their scores
+// and the cursor of the next batch, 0 when the scan is complete
+func (r *ReconcileRepository) ScanRanking(ctx context.Context, cursor uint64, count int64) (map[string]float64, uint64, error) {
+	pairs, next, err := r.redisClient.ZScan(ctx, constant.VideoRanking, cursor, "", count).Result()
+	if err != nil {
+		log.Printf("Failed to scan the global ranking: %v", err)
+		return nil, 0, err
+	}
+	scores := make(map[string]float64, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(pairs[i+1], 64)
+		if err != nil {
+			continue
+		}
+		scores[pairs[i]] = score
+	}
+	return scores, next, nil
+}
+
+// RepairCachedScore sets a video's ranking score if its cached score is still expected, nil meaning
+// absent. A nil score removes the video from the ranking. It reports whether the ranking was changed.
+func (r *ReconcileRepository) RepairCachedScore(ctx context.Context, videoID string, expected *float64, score *float64) (bool, error) {
+	repaired, err := repairCachedScoreScript.Run(ctx, r.redisClient, []string{constant.VideoRanking},
+		videoID, formatScore(expected), formatScore(score)).Int()
+	if err != nil {
+		log.Printf("Failed to repair cached score of video %s: %v", videoID, err)
+		return false, err
+	}
+	return repaired == 1, nil
+}
+
+// formatScore formats a score as a script argument without losing precision, nil as empty
+func formatScore(score *float64) string {
+	if score == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*score, 'g', 17, 64)
+}
+
+// SaveRun stores a pass as the last run and adds its counters to the totals
+func (r *ReconcileRepository) SaveRun(ctx context.Context, run *entity.ReconcileRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	pipe := r.redisClient.TxPipeline()
+	pipe.Set(ctx, constant.ReconcileLastRun, data, 0)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "runs", 1)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "checked", run.Checked)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "mismatched", run.Mismatched)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "missing", run.Missing)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "stale", run.Stale)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "pending", run.Pending)
+	pipe.HIncrBy(ctx, constant.ReconcileTotals, "repaired", run.Repaired)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to save reconcile run: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetStats retrieves the reconciliation totals and the last run
+func (r *ReconcileRepository) GetStats(ctx context.Context) (*entity.ReconcileStats, error) {
+	stats := &entity.ReconcileStats{}
+	if err := r.redisClient.HGetAll(ctx, constant.ReconcileTotals).Scan(stats); err != nil {
+		log.Printf("Failed to get reconcile totals: %v", err)
+		return nil, err
+	}
+
+	data, err := r.redisClient.Get(ctx, constant.ReconcileLastRun).Bytes()
+	if err == redis.Nil {
+		return stats, nil
+	}
+	if err != nil {
+		log.Printf("Failed to get last reconcile run: %v", err)
+		return nil, err
+	}
+	stats.LastRun = &entity.ReconcileRun{}
+	if err := json.Unmarshal(data, stats.LastRun); err != nil {
+		log.Printf("Failed to decode last reconcile run: %v", err)
+		return nil, err
+	}
+	return stats, nil
+}
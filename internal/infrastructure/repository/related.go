@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RelatedRepository reads user engagement from MongoDB and keeps the related videos of every video
+// as a ZSET of neighbors scored by similarity in Redis
+type RelatedRepository struct {
+	personalCollection    *mongo.Collection
+	interactionCollection *mongo.Collection
+	redisClient           *redis.Client
+}
+
+// NewRelatedRepository initializes the repository
+func NewRelatedRepository(db *mongo.Database, redisClient *redis.Client) *RelatedRepository {
+	return &RelatedRepository{
+		personalCollection:    db.Collection("personal_scores"),
+		interactionCollection: db.Collection(InteractionCollectionName),
+		redisClient:           redisClient,
+	}
+}
+
+// EnsureIndexes creates the index used to find a user's recent interactions
+func (r *RelatedRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.interactionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}); err != nil {
+		log.Printf("Failed to create interaction indexes: %v", err)
+		return err
+	}
+	return nil
+}
+
+// AcquireLease takes the related videos job lease for owner if no one holds it
+func (r *RelatedRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := r.redisClient.SetNX(ctx, constant.RelatedLease, owner, ttl).Result()
+	if err != nil {
+		log.Printf("Failed to acquire related videos lease: %v", err)
+		return false, err
+	}
+	return acquired, nil
+}
+
+// ScanEngagements streams the positive personal scores ordered by user and passes each user's
+// top maxVideos videos to fn, stopping at the first error
+func (r *RelatedRepository) ScanEngagements(
+	ctx context.Context, batchSize int, maxVideos int, fn func(userID string, videoIDs []string) error,
+) error {
+	cursor, err := r.personalCollection.Find(ctx, bson.M{"score": bson.M{"$gt": 0}}, options.Find().
+		SetBatchSize(int32(batchSize)).
+		SetSort(bson.D{{Key: "user_id", Value: 1}, {Key: "score", Value: -1}}).
+		SetProjection(bson.M{"user_id": 1, "video_id": 1}))
+	if err != nil {
+		log.Printf("Failed to read personal scores: %v", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	current := ""
+	var videoIDs []string
+	for cursor.Next(ctx) {
+		var doc struct {
+			UserID  string `bson:"user_id"`
+			VideoID string `bson:"video_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("Failed to decode personal score: %v", err)
+			return err
+		}
+		if doc.UserID != current {
+			if current != "" {
+				if err := fn(current, videoIDs); err != nil {
+					return err
+				}
+			}
+			current, videoIDs = doc.UserID, nil
+		}
+		if len(videoIDs) < maxVideos {
+			videoIDs = append(videoIDs, doc.VideoID)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Failed to read personal scores: %v", err)
+		return err
+	}
+	if current != "" {
+		return fn(current, videoIDs)
+	}
+	return nil
+}
+
+// SaveRelated replaces the related videos of each video, writing them to a temporary ZSET renamed
+// over the previous one, expiring after ttl. Writes are pipelined in batches of batchSize videos.
+func (r *RelatedRepository) SaveRelated(
+	ctx context.Context, related map[string][]*entity.RelatedVideo, batchSize int, ttl time.Duration,
+) error {
+	pipe := r.redisClient.Pipeline()
+	for videoID, neighbors := range related {
+		if len(neighbors) == 0 {
+			continue
+		}
+		key := constant.RelatedVideosPrefix + videoID
+		tmp := constant.RebuildKeyPrefix + key
+		members := make([]*redis.Z, len(neighbors))
+		for i, neighbor := range neighbors {
+			members[i] = &redis.Z{Score: neighbor.Score, Member: neighbor.VideoID}
+		}
+		pipe.Del(ctx, tmp)
+		pipe.ZAdd(ctx, tmp, members...)
+		pipe.Expire(ctx, tmp, ttl)
+		pipe.Rename(ctx, tmp, key)
+
+		if pipe.Len() >= batchSize*4 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				log.Printf("Failed to save related videos: %v", err)
+				return err
+			}
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to save related videos: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRelated retrieves up to limit related videos of a video, most similar first
+func (r *RelatedRepository) GetRelated(ctx context.Context, videoID string, limit int64) ([]*entity.RelatedVideo, error) {
+	members, err := r.redisClient.ZRevRangeWithScores(ctx, constant.RelatedVideosPrefix+videoID, 0, limit-1).Result()
+	if err != nil {
+		log.Printf("Failed to get related videos of video %s: %v", videoID, err)
+		return nil, err
+	}
+	return relatedVideos(members), nil
+}
+
+// GetRelatedOf retrieves up to limit related videos of several videos in one pipeline
+func (r *RelatedRepository) GetRelatedOf(ctx context.Context, videoIDs []string, limit int64) (map[string][]*entity.RelatedVideo, error) {
+	pipe := r.redisClient.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		cmds[i] = pipe.ZRevRangeWithScores(ctx, constant.RelatedVideosPrefix+videoID, 0, limit-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("Failed to get related videos of %d videos: %v", len(videoIDs), err)
+		return nil, err
+	}
+
+	related := make(map[string][]*entity.RelatedVideo, len(videoIDs))
+	for i, videoID := range videoIDs {
+		related[videoID] = relatedVideos(cmds[i].Val())
+	}
+	return related, nil
+}
+
+// relatedVideos converts ZSET members to related videos
+func relatedVideos(members []redis.Z) []*entity.RelatedVideo {
+	videos := make([]*entity.RelatedVideo, len(members))
+	for i, member := range members {
+		videos[i] = &entity.RelatedVideo{VideoID: member.Member.(string), Score: member.Score}
+	}
+	return videos
+}
+
+// GetRecentVideos retrieves the last limit distinct videos a user interacted with, most recent first,
+// looking at their last 10 * limit interactions and ignoring retracted ones
+func (r *RelatedRepository) GetRecentVideos(ctx context.Context, userID string, limit int64) ([]string, error) {
+	cursor, err := r.interactionCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "retracted_at": bson.M{"$exists": false}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
+		{{Key: "$limit", Value: limit * 10}},
+		{{Key: "$group", Value: bson.M{"_id": "$video_id", "last": bson.M{"$max": "$created_at"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "last", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	})
+	if err != nil {
+		log.Printf("Failed to get recent videos of user %s: %v", userID, err)
+		return nil, err
+	}
+
+	var results []struct {
+		VideoID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		log.Printf("Failed to decode recent videos of user %s: %v", userID, err)
+		return nil, err
+	}
+	videoIDs := make([]string, len(results))
+	for i, result := range results {
+		videoIDs[i] = result.VideoID
+	}
+	return videoIDs, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// defaultInteractionRules are used when no rules file is configured
+var defaultInteractionRules = map[constant.InteractionType]*entity.InteractionRule{
+	constant.Like:    {Limit: 1},
+	constant.Share:   {Limit: 3, WindowSeconds: 86400},
+	constant.View:    {Limit: 10, WindowSeconds: 3600},
+	constant.Comment: {DecayAfter: 3, DecayFactor: 0.5, WindowSeconds: 86400},
+}
+
+// LoadInteractionRules reads the anti-spam rules from a JSON file keyed by interaction type,
+// or returns the built-in defaults when file is empty
+func LoadInteractionRules(file string) (map[constant.InteractionType]*entity.InteractionRule, error) {
+	if file == "" {
+		return defaultInteractionRules, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.Printf("Failed to read rules file %s: %v", file, err)
+		return nil, err
+	}
+	rules := map[constant.InteractionType]*entity.InteractionRule{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Printf("Failed to parse rules file %s: %v", file, err)
+		return nil, err
+	}
+	for interactionType, rule := range rules {
+		if rule.Limit < 0 || rule.WindowSeconds < 0 || rule.DecayAfter < 0 {
+			return nil, fmt.Errorf("invalid rule for interaction type %q: negative value", interactionType)
+		}
+		if rule.DecayAfter > 0 && (math.IsNaN(rule.DecayFactor) || rule.DecayFactor < 0 || rule.DecayFactor > 1) {
+			return nil, fmt.Errorf("invalid rule for interaction type %q: decay_factor must be between 0 and 1", interactionType)
+		}
+	}
+	return rules, nil
+}
+
+var incrementCounterScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+var decrementCounterScript = redis.NewScript(`
+if tonumber(redis.call('GET', KEYS[1]) or 0) > 0 then
+	return redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+// CounterRepository counts interactions per key in Redis within fixed windows
+type CounterRepository struct {
+	redisClient *redis.Client
+}
+
+// NewCounterRepository initializes the repository
+func NewCounterRepository(redisClient *redis.Client) *CounterRepository {
+	return &CounterRepository{redisClient: redisClient}
+}
+
+// Increment adds one to a counter and returns the new count. The window starts with the
+// first increment, a zero window keeps the counter forever.
+func (r *CounterRepository) Increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := incrementCounterScript.Run(ctx, r.redisClient, []string{constant.InteractionCountPrefix + key},
+		int64(window.Seconds())).Int64()
+	if err != nil {
+		log.Printf("Failed to increment counter %s: %v", key, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Decrement takes one from a counter that has not expired, never going below zero
+func (r *CounterRepository) Decrement(ctx context.Context, key string) error {
+	if err := decrementCounterScript.Run(ctx, r.redisClient, []string{constant.InteractionCountPrefix + key}).Err(); err != nil {
+		log.Printf("Failed to decrement counter %s: %v", key, err)
+		return err
+	}
+	return nil
+}
@@ -2,76 +2,426 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"go-server/internal/common/constant"
+	"go-server/internal/common/util"
+	"go-server/internal/entity"
 
 	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// applyCachedScoreScript increments the global, personal, hot, windowed and segment ZSETs once per event.
+// The hot contribution is delta * 2^((occurred at - epoch) / half-life), so newer events weigh
+// exponentially more and the ranking order equals the decayed order without rescoring on read.
+// A bucket TTL of 0 skips that bucket, which is used for events older than the bucket retention.
+// After the hour bucket is updated, the video's trending velocity for that hour is recomputed as
+// (hour score + smoothing) / (average baseline hour score + smoothing).
+// The personal ranking is set to the user's stored personal score rather than incremented, so a video
+// trimmed from it comes back with its full score. A missing personal ranking is left for lazy rehydration,
+// an existing one is trimmed to its lowest-ranked max size members and its idle TTL refreshed, 0 disabling either.
+// The segment rankings passed last are incremented like the global ranking.
+// KEYS: processed marker, global ranking, personal ranking, hot ranking, hot epoch, hour bucket, day bucket,
+// trending ranking of the hour, baseline hour buckets..., segment rankings...
+// ARGV: marker TTL (seconds), delta, video ID, occurred at (unix seconds), half-life (seconds), now (unix seconds),
+// hour bucket TTL (seconds), day bucket TTL (seconds), smoothing, personal score, personal max size,
+// personal idle TTL (seconds), number of segment rankings.
+var applyCachedScoreScript = redis.NewScript(`
+if not redis.call('SET', KEYS[1], 1, 'NX', 'EX', ARGV[1]) then
+	return 0
+end
+local function incr(key, delta)
+	if tonumber(redis.call('ZINCRBY', key, delta, ARGV[3])) < 0 then
+		redis.call('ZADD', key, 0, ARGV[3])
+	end
+end
+incr(KEYS[2], ARGV[2])
+local baselineEnd = #KEYS - tonumber(ARGV[13])
+for i = baselineEnd + 1, #KEYS do
+	incr(KEYS[i], ARGV[2])
+end
+if redis.call('EXISTS', KEYS[3]) == 1 then
+	redis.call('ZADD', KEYS[3], ARGV[10], ARGV[3])
+	if tonumber(ARGV[11]) > 0 then
+		redis.call('ZREMRANGEBYRANK', KEYS[3], 0, -tonumber(ARGV[11]) - 1)
+	end
+	if tonumber(ARGV[12]) > 0 then
+		redis.call('EXPIRE', KEYS[3], ARGV[12])
+	end
+end
+local epoch = tonumber(redis.call('GET', KEYS[5]))
+if not epoch then
+	epoch = tonumber(ARGV[6])
+	redis.call('SET', KEYS[5], ARGV[6])
+end
+local hot = tonumber(ARGV[2]) * 2 ^ ((tonumber(ARGV[4]) - epoch) / tonumber(ARGV[5]))
+incr(KEYS[4], string.format('%.17g', hot))
+for i = 6, 7 do
+	local ttl = tonumber(ARGV[i + 1])
+	if ttl > 0 then
+		incr(KEYS[i], ARGV[2])
+		redis.call('EXPIRE', KEYS[i], ttl)
+	end
+end
+if tonumber(ARGV[7]) > 0 and baselineEnd > 8 then
+	local current = tonumber(redis.call('ZSCORE', KEYS[6], ARGV[3])) or 0
+	local baseline = 0
+	for i = 9, baselineEnd do
+		baseline = baseline + (tonumber(redis.call('ZSCORE', KEYS[i], ARGV[3])) or 0)
+	end
+	baseline = baseline / (baselineEnd - 8)
+	local smoothing = tonumber(ARGV[9])
+	local velocity = (current + smoothing) / (baseline + smoothing)
+	redis.call('ZADD', KEYS[8], string.format('%.17g', velocity), ARGV[3])
+	redis.call('EXPIRE', KEYS[8], ARGV[7])
+end
+return 1
+`)
+
+// subtractCachedScoreScript adds a negative delta to a video in a ranking without taking it below zero.
+// KEYS: ranking. ARGV: delta, video ID.
+var subtractCachedScoreScript = redis.NewScript(`
+if tonumber(redis.call('ZINCRBY', KEYS[1], ARGV[1], ARGV[2])) < 0 then
+	redis.call('ZADD', KEYS[1], 0, ARGV[2])
+end
+return 1
+`)
+
+// subtractEventScript takes one applied event out of the hot, windowed, trending and segment rankings
+// of its video, the opposite of applyCachedScoreScript. Rankings the video is no longer in, such as expired
+// buckets, are left alone, and no score goes below zero. The hot amount is decayed against the current epoch,
+// and the video's trending velocity of the event's hour is recomputed if it is still ranked.
+// KEYS: hot ranking, hot epoch, hour bucket, day bucket, trending ranking of the hour, baseline hour buckets...,
+// segment rankings...
+// ARGV: increment (the negated event delta), video ID, occurred at (unix seconds), half-life (seconds),
+// smoothing, number of segment rankings.
+var subtractEventScript = redis.NewScript(`
+local function add(key, increment)
+	if redis.call('ZSCORE', key, ARGV[2]) then
+		if tonumber(redis.call('ZINCRBY', key, increment, ARGV[2])) < 0 then
+			redis.call('ZADD', key, 0, ARGV[2])
+		end
+	end
+end
+local epoch = tonumber(redis.call('GET', KEYS[2]))
+if epoch then
+	add(KEYS[1], string.format('%.17g', tonumber(ARGV[1]) * 2 ^ ((tonumber(ARGV[3]) - epoch) / tonumber(ARGV[4]))))
+end
+add(KEYS[3], ARGV[1])
+add(KEYS[4], ARGV[1])
+local baselineEnd = #KEYS - tonumber(ARGV[6])
+for i = baselineEnd + 1, #KEYS do
+	add(KEYS[i], ARGV[1])
+end
+if baselineEnd > 5 and redis.call('ZSCORE', KEYS[5], ARGV[2]) then
+	local current = tonumber(redis.call('ZSCORE', KEYS[3], ARGV[2])) or 0
+	local baseline = 0
+	for i = 6, baselineEnd do
+		baseline = baseline + (tonumber(redis.call('ZSCORE', KEYS[i], ARGV[2])) or 0)
+	end
+	baseline = baseline / (baselineEnd - 5)
+	local smoothing = tonumber(ARGV[5])
+	redis.call('ZADD', KEYS[5], string.format('%.17g', (current + smoothing) / (baseline + smoothing)), ARGV[2])
+end
+return 1
+`)
+
+// rebaseHotRankingScript moves the hot epoch to now, scaling every hot score down accordingly
+// so they stay within float range, and drops members whose score fell below the minimum.
+// KEYS: hot ranking, hot epoch. ARGV: now (unix seconds), half-life (seconds), min score.
+var rebaseHotRankingScript = redis.NewScript(`
+local epoch = tonumber(redis.call('GET', KEYS[2]))
+if not epoch then
+	return 0
+end
+local factor = 2 ^ ((epoch - tonumber(ARGV[1])) / tonumber(ARGV[2]))
+local members = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+for i = 1, #members, 2 do
+	redis.call('ZADD', KEYS[1], string.format('%.17g', tonumber(members[i + 1]) * factor), members[i])
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[3])
+redis.call('SET', KEYS[2], ARGV[1])
+return #members / 2
+`)
+
 type ScoreRepository struct {
+	db                 *mongo.Database
 	collection         *mongo.Collection
 	personalCollection *mongo.Collection
+	ledgerCollection   *mongo.Collection
 	redisClient        *redis.Client
 }
 
 func NewScoreRepository(db *mongo.Database, redisClient *redis.Client) *ScoreRepository {
 	return &ScoreRepository{
+		db:                 db,
 		collection:         db.Collection("video_scores"),
 		personalCollection: db.Collection("personal_scores"),
+		ledgerCollection:   db.Collection("processed_events"),
 		redisClient:        redisClient,
 	}
 }
 
-// GetByVideo retrieves the score of a video by its ID
-func (r *ScoreRepository) GetByVideo(
-	ctx context.Context, videoID string,
-) (float64, error) {
-	filter := bson.M{"video_id": videoID}
-	var result struct {
-		Score float64 `bson:"score"`
+// EnsureIndexes creates the unique indexes that keep one score document per video and per user/video,
+// and a TTL index expiring processed event ledger entries after ledgerTTL
+func (r *ScoreRepository) EnsureIndexes(ctx context.Context, ledgerTTL time.Duration) error {
+	if _, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "video_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		log.Printf("Failed to create video score indexes: %v", err)
+		return err
 	}
-	if err := r.collection.FindOne(ctx, filter).Decode(&result); err != nil {
-		log.Printf("Failed to get score for video %s: %v", videoID, err)
-		return 0, err
+	if _, err := r.personalCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "video_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		log.Printf("Failed to create personal score indexes: %v", err)
+		return err
 	}
-	log.Printf("Successfully retrieved score for video %s: %f", videoID, result.Score)
-	return result.Score, nil
+	if _, err := r.ledgerCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "video_id", Value: 1}},
+	}); err != nil {
+		log.Printf("Failed to create processed event indexes: %v", err)
+		return err
+	}
+	if _, err := r.ledgerCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "video_id", Value: 1}, {Key: "processed_at", Value: 1}},
+	}); err != nil {
+		log.Printf("Failed to create processed event video index: %v", err)
+		return err
+	}
+	if _, err := r.ledgerCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "processed_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(ledgerTTL.Seconds())),
+	}); err != nil {
+		log.Printf("Failed to create processed event indexes: %v", err)
+		return err
+	}
+	return nil
 }
 
-// IncrementScore increments the score of a video by a given value
-func (r *ScoreRepository) IncrementScore(ctx context.Context, videoID string, increment float64) error {
-	filter := bson.M{"video_id": videoID}
-	update := bson.M{
-		"$inc": bson.M{
-			"score": increment,
-		},
+// errAlreadyApplied aborts the ApplyScore transaction when another consumer recorded the event first
+var errAlreadyApplied = errors.New("event already applied")
+
+// ApplyScore records the event in the ledger and upserts the video and personal scores by delta
+// in a single transaction. A negative delta is capped at the user's personal score and the ledger records
+// the delta actually applied. It returns false without changing anything if the event was already applied,
+// so a redelivered event can still finish its Redis part. The ledger is checked before the transaction,
+// as a duplicate key inside it aborts the transaction.
+func (r *ScoreRepository) ApplyScore(ctx context.Context, ledger *entity.ProcessedEvent) (bool, error) {
+	processed, err := r.GetProcessedEvent(ctx, ledger.EventID)
+	if err != nil {
+		return false, err
+	}
+	if processed != nil {
+		return false, nil
 	}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+	session, err := r.db.Client().StartSession()
 	if err != nil {
-		log.Printf("Failed to increment score for video %s: %v", videoID, err)
-		return err
+		log.Printf("Failed to start session: %v", err)
+		return false, err
 	}
-	log.Printf("Successfully incremented score for video %s by %f", videoID, increment)
-	return nil
+	defer session.EndSession(ctx)
+
+	upsert := options.Update().SetUpsert(true)
+	delta := ledger.Delta
+	personalFilter := bson.M{"user_id": ledger.UserID, "video_id": ledger.VideoID}
+
+	applied, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		ledger.Delta = delta
+		if delta < 0 {
+			// A retraction never removes more than the user has left on the video, e.g. after their
+			// contribution was subtracted, so other users' contributions stay intact
+			var personal struct {
+				Score float64 `bson:"score"`
+			}
+			if err := r.personalCollection.FindOne(sc, personalFilter).Decode(&personal); err != nil && err != mongo.ErrNoDocuments {
+				return false, err
+			}
+			ledger.Delta = max(delta, -personal.Score)
+		}
+		update := scoreUpdate(ledger.Delta)
+
+		if _, err := r.ledgerCollection.InsertOne(sc, ledger); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return false, errAlreadyApplied
+			}
+			return false, err
+		}
+		if _, err := r.collection.UpdateOne(sc, bson.M{"video_id": ledger.VideoID}, update, upsert); err != nil {
+			return false, err
+		}
+		if _, err := r.personalCollection.UpdateOne(sc, personalFilter, update, upsert); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if errors.Is(err, errAlreadyApplied) {
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("Failed to apply score of event %s: %v", ledger.EventID, err)
+		return false, err
+	}
+	log.Printf("Applied score of event %s for user %s on video %s: %t", ledger.EventID, ledger.UserID, ledger.VideoID, applied)
+	return applied.(bool), nil
+}
+
+// InsertTombstone records a ledger entry for an event that was retracted before it was applied, marked as
+// subtracted so the event applies nothing when it arrives. It returns false if the event was applied meanwhile.
+func (r *ScoreRepository) InsertTombstone(ctx context.Context, ledger *entity.ProcessedEvent) (bool, error) {
+	if _, err := r.ledgerCollection.InsertOne(ctx, ledger); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		log.Printf("Failed to insert tombstone of event %s: %v", ledger.EventID, err)
+		return false, err
+	}
+	return true, nil
 }
 
-// InsertOne inserts a new video score into the collection
-func (r *ScoreRepository) InsertOne(ctx context.Context, videoID string, score float64) error {
-	document := bson.M{
-		"video_id": videoID,
-		"score":    score,
+// scoreUpdate increments a score document by delta. Negative deltas never take the score below zero.
+func scoreUpdate(delta float64) interface{} {
+	if delta >= 0 {
+		return bson.M{"$inc": bson.M{"score": delta}}
 	}
-	_, err := r.collection.InsertOne(ctx, document)
+	return mongo.Pipeline{{{Key: "$set", Value: bson.M{"score": bson.M{"$max": bson.A{
+		0, bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$score", 0}}, delta}},
+	}}}}}}
+}
+
+// SubtractUserContribution removes a user's personal scores from the video scores and the global
+// ranking, takes each of the user's ledgered events out of the hot, windowed, trending and segment
+// rankings, then resets the user's personal scores and ranking. Each video is adjusted in its own
+// transaction, guarded by the personal score it read, which also marks the user's ledger entries of
+// the video as subtracted so a later retraction of them subtracts nothing. Running it again does not
+// subtract twice. Events whose ledger entry expired are only removed from the global ranking.
+// It returns the number of videos adjusted.
+func (r *ScoreRepository) SubtractUserContribution(
+	ctx context.Context, userID string, policy *entity.RankingPolicy,
+) (int64, error) {
+	cursor, err := r.personalCollection.Find(ctx, bson.M{"user_id": userID, "score": bson.M{"$gt": 0}})
 	if err != nil {
-		log.Printf("Failed to insert score for video %s: %v", videoID, err)
-		return err
+		log.Printf("Failed to get personal scores of user %s: %v", userID, err)
+		return 0, err
 	}
-	log.Printf("Successfully inserted score for video %s", videoID)
-	return nil
+	var contributions []struct {
+		VideoID string  `bson:"video_id"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &contributions); err != nil {
+		log.Printf("Failed to decode personal scores of user %s: %v", userID, err)
+		return 0, err
+	}
+
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		log.Printf("Failed to start session: %v", err)
+		return 0, err
+	}
+	defer session.EndSession(ctx)
+
+	var adjusted int64
+	for _, contribution := range contributions {
+		var events []*entity.ProcessedEvent
+		subtracted, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			result, err := r.personalCollection.UpdateOne(sc,
+				bson.M{"user_id": userID, "video_id": contribution.VideoID, "score": contribution.Score},
+				bson.M{"$set": bson.M{"score": 0}},
+			)
+			if err != nil || result.ModifiedCount == 0 {
+				return false, err
+			}
+			if _, err := r.collection.UpdateOne(sc, bson.M{"video_id": contribution.VideoID}, scoreUpdate(-contribution.Score)); err != nil {
+				return false, err
+			}
+
+			ledgerFilter := bson.M{"user_id": userID, "video_id": contribution.VideoID, "subtracted_at": bson.M{"$exists": false}}
+			cursor, err := r.ledgerCollection.Find(sc, ledgerFilter)
+			if err != nil {
+				return false, err
+			}
+			events = nil
+			if err := cursor.All(sc, &events); err != nil {
+				return false, err
+			}
+			if _, err := r.ledgerCollection.UpdateMany(sc, ledgerFilter,
+				bson.M{"$set": bson.M{"subtracted_at": time.Now()}}); err != nil {
+				return false, err
+			}
+			return true, nil
+		})
+		if err != nil {
+			log.Printf("Failed to subtract contribution of user %s on video %s: %v", userID, contribution.VideoID, err)
+			return adjusted, err
+		}
+		if !subtracted.(bool) {
+			continue
+		}
+		if err := subtractCachedScoreScript.Run(ctx, r.redisClient, []string{constant.VideoRanking},
+			-contribution.Score, contribution.VideoID).Err(); err != nil {
+			log.Printf("Failed to subtract cached contribution of user %s on video %s: %v", userID, contribution.VideoID, err)
+			return adjusted, err
+		}
+		for _, event := range events {
+			if err := r.subtractEvent(ctx, event, policy); err != nil {
+				log.Printf("Failed to subtract event %s of user %s from the rankings: %v", event.EventID, userID, err)
+				return adjusted, err
+			}
+		}
+		adjusted++
+	}
+
+	if err := r.redisClient.Del(ctx, constant.PersonalRankingPrefix+userID).Err(); err != nil {
+		log.Printf("Failed to reset personal ranking of user %s: %v", userID, err)
+		return adjusted, err
+	}
+	log.Printf("Subtracted contribution of user %s from %d videos", userID, adjusted)
+	return adjusted, nil
+}
+
+// subtractEvent takes an applied event out of the hot, windowed, trending and segment rankings of its video
+func (r *ScoreRepository) subtractEvent(ctx context.Context, event *entity.ProcessedEvent, policy *entity.RankingPolicy) error {
+	keys := []string{
+		constant.HotVideoRanking,
+		constant.HotVideoRankingEpoch,
+		constant.HourlyVideoRankingPrefix + util.HourBucket(event.OccurredAt),
+		constant.DailyVideoRankingPrefix + util.DayBucket(event.OccurredAt),
+		constant.TrendingVideoRankingPrefix + util.HourBucket(event.OccurredAt),
+	}
+	for i := 1; i <= policy.TrendingBaselineHours; i++ {
+		keys = append(keys, constant.HourlyVideoRankingPrefix+util.HourBucket(event.OccurredAt.Add(-time.Duration(i)*time.Hour)))
+	}
+	for _, segment := range event.Segments {
+		keys = append(keys, constant.SegmentVideoRankingPrefix+segment)
+	}
+	return subtractEventScript.Run(ctx, r.redisClient, keys,
+		-event.Delta, event.VideoID, event.OccurredAt.Unix(), policy.HotHalfLife.Seconds(),
+		policy.TrendingSmoothing, len(event.Segments),
+	).Err()
+}
+
+// GetProcessedEvent retrieves the ledger entry of an applied event, or nil if it is unknown or expired
+func (r *ScoreRepository) GetProcessedEvent(ctx context.Context, eventID string) (*entity.ProcessedEvent, error) {
+	var ledger entity.ProcessedEvent
+	if err := r.ledgerCollection.FindOne(ctx, bson.M{"_id": eventID}).Decode(&ledger); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Failed to get processed event %s: %v", eventID, err)
+		return nil, err
+	}
+	return &ledger, nil
 }
 
 // GetPersonalScore retrieves the personal score of a user for a specific video
@@ -88,85 +438,760 @@ func (r *ScoreRepository) GetPersonalScore(ctx context.Context, userID string, v
 	return result.Score, nil
 }
 
-// IncrementPersonalScore increments the personal score of a user for a specific video by a given value
-func (r *ScoreRepository) IncrementPersonalScore(ctx context.Context, userID string, videoID string, increment float64) error {
-	filter := bson.M{"user_id": userID, "video_id": videoID}
+// GetByVideos retrieves the scores of several videos, videos without a score are left out
+func (r *ScoreRepository) GetByVideos(ctx context.Context, videoIDs []string) (map[string]float64, error) {
+	scores, err := r.findScores(ctx, r.collection, bson.M{"video_id": bson.M{"$in": videoIDs}})
+	if err != nil {
+		log.Printf("Failed to get scores for %d videos: %v", len(videoIDs), err)
+		return nil, err
+	}
+	log.Printf("Successfully retrieved scores for %d of %d videos", len(scores), len(videoIDs))
+	return scores, nil
+}
 
-	update := bson.M{
-		"$inc": bson.M{
-			"score": increment,
-		},
+// GetPersonalScores retrieves the personal scores of a user for several videos, videos without a score are left out
+func (r *ScoreRepository) GetPersonalScores(ctx context.Context, userID string, videoIDs []string) (map[string]float64, error) {
+	scores, err := r.findScores(ctx, r.personalCollection, bson.M{"user_id": userID, "video_id": bson.M{"$in": videoIDs}})
+	if err != nil {
+		log.Printf("Failed to get personal scores for user %s: %v", userID, err)
+		return nil, err
 	}
-	_, err := r.personalCollection.UpdateOne(ctx, filter, update)
+	log.Printf("Successfully retrieved personal scores for user %s on %d of %d videos", userID, len(scores), len(videoIDs))
+	return scores, nil
+}
+
+// findScores reads the score documents matching filter keyed by video ID
+func (r *ScoreRepository) findScores(ctx context.Context, collection *mongo.Collection, filter bson.M) (map[string]float64, error) {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"video_id": 1, "score": 1}))
 	if err != nil {
-		log.Printf("Failed to increment personal score for user %s and video %s: %v", userID, videoID, err)
-		return err
+		return nil, err
 	}
-	log.Printf("Successfully incremented personal score for user %s and video %s by %f", userID, videoID, increment)
+	var results []struct {
+		VideoID string  `bson:"video_id"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(results))
+	for _, result := range results {
+		scores[result.VideoID] = result.Score
+	}
+	return scores, nil
+}
 
-	return nil
+// GetCachedScores retrieves the cached global score and rank of several videos in one pipeline,
+// videos missing from the ranking are left out
+func (r *ScoreRepository) GetCachedScores(ctx context.Context, videoIDs []string) (map[string]*entity.VideoScore, error) {
+	pipe := r.redisClient.Pipeline()
+	scoreCmds := make([]*redis.FloatCmd, len(videoIDs))
+	rankCmds := make([]*redis.IntCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		scoreCmds[i] = pipe.ZScore(ctx, constant.VideoRanking, videoID)
+		rankCmds[i] = pipe.ZRevRank(ctx, constant.VideoRanking, videoID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("Failed to get cached scores for %d videos: %v", len(videoIDs), err)
+		return nil, err
+	}
+
+	scores := make(map[string]*entity.VideoScore, len(videoIDs))
+	for i, videoID := range videoIDs {
+		score, err := scoreCmds[i].Result()
+		if err != nil {
+			continue
+		}
+		scores[videoID] = &entity.VideoScore{
+			VideoID: videoID,
+			Score:   score,
+			Rank:    rankCmds[i].Val() + 1,
+		}
+	}
+	return scores, nil
+}
+
+// GetCachedPersonalScores retrieves the cached personal scores of a user for several videos in one pipeline,
+// videos missing from the user's ranking are left out
+func (r *ScoreRepository) GetCachedPersonalScores(ctx context.Context, userID string, videoIDs []string) (map[string]float64, error) {
+	pipe := r.redisClient.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		cmds[i] = pipe.ZScore(ctx, constant.PersonalRankingPrefix+userID, videoID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("Failed to get cached personal scores for user %s: %v", userID, err)
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(videoIDs))
+	for i, videoID := range videoIDs {
+		if score, err := cmds[i].Result(); err == nil {
+			scores[videoID] = score
+		}
+	}
+	return scores, nil
 }
 
-// InsertPersonalScore inserts a new personal score for a user and video into the collection
-func (r *ScoreRepository) InsertPersonalScore(ctx context.Context, userID string, videoID string, score float64) error {
-	document := bson.M{
-		"user_id":  userID,
-		"video_id": videoID,
-		"score":    score,
+// GetTopRankedVideos retrieves a page of the top videos from the Redis Sorted Set
+func (r *ScoreRepository) GetTopRankedVideos(ctx context.Context, after *entity.RankingCursor, limit int64) ([]*entity.RankedVideo, error) {
+	videos, err := r.rankingPage(ctx, constant.VideoRanking, after, limit)
+	if err != nil {
+		log.Printf("Failed to get top-ranked videos: %v", err)
+		return nil, err
 	}
-	_, err := r.personalCollection.InsertOne(ctx, document)
+	log.Printf("Successfully retrieved top %d ranked videos", limit)
+	return videos, nil
+}
+
+// GetPersonalTopRankedVideos retrieves a page of the top personalized videos for a user
+func (r *ScoreRepository) GetPersonalTopRankedVideos(
+	ctx context.Context, userID string, after *entity.RankingCursor, limit int64,
+) ([]*entity.RankedVideo, error) {
+	videos, err := r.rankingPage(ctx, constant.PersonalRankingPrefix+userID, after, limit)
 	if err != nil {
-		log.Printf("Failed to insert personal score for user %s and video %s: %v", userID, videoID, err)
+		log.Printf("Failed to get personalized top-ranked videos for user %s: %v", userID, err)
+		return nil, err
+	}
+	log.Printf("Successfully retrieved personalized top %d ranked videos for user %s", limit, userID)
+	return videos, nil
+}
+
+// RehydratePersonalRanking loads a user's top maxSize personal scores from MongoDB into their personal
+// ranking if it is missing, all of them if maxSize is 0, expiring it after ttl unless 0. The scores are
+// written to a temporary ZSET renamed only if the ranking is still missing, so a concurrent rehydration
+// is not overwritten. It reports whether the ranking was rehydrated.
+func (r *ScoreRepository) RehydratePersonalRanking(
+	ctx context.Context, userID string, maxSize int64, ttl time.Duration,
+) (bool, error) {
+	key := constant.PersonalRankingPrefix + userID
+	exists, err := r.redisClient.Exists(ctx, key).Result()
+	if err != nil {
+		log.Printf("Failed to check personal ranking of user %s: %v", userID, err)
+		return false, err
+	}
+	if exists == 1 {
+		return false, nil
+	}
+
+	cursor, err := r.personalCollection.Find(ctx, bson.M{"user_id": userID}, options.Find().
+		SetSort(bson.D{{Key: "score", Value: -1}}).
+		SetLimit(maxSize).
+		SetProjection(bson.M{"video_id": 1, "score": 1}))
+	if err != nil {
+		log.Printf("Failed to read personal scores of user %s: %v", userID, err)
+		return false, err
+	}
+	var results []struct {
+		VideoID string  `bson:"video_id"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		log.Printf("Failed to decode personal scores of user %s: %v", userID, err)
+		return false, err
+	}
+	if len(results) == 0 {
+		return false, nil
+	}
+
+	members := make([]*redis.Z, len(results))
+	for i, result := range results {
+		members[i] = &redis.Z{Score: result.Score, Member: result.VideoID}
+	}
+	tmp := constant.RebuildKeyPrefix + key
+	pipe := r.redisClient.TxPipeline()
+	pipe.Del(ctx, tmp)
+	pipe.ZAdd(ctx, tmp, members...)
+	if ttl > 0 {
+		pipe.Expire(ctx, tmp, ttl)
+	}
+	renamed := pipe.RenameNX(ctx, tmp, key)
+	pipe.Del(ctx, tmp)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to rehydrate personal ranking of user %s: %v", userID, err)
+		return false, err
+	}
+	log.Printf("Rehydrated personal ranking of user %s with %d videos", userID, len(members))
+	return renamed.Val(), nil
+}
+
+// ApplyCachedScore increments the global, hot, windowed and segment ranking ZSETs by the event's delta and
+// sets the video's personal score, unless the event was already applied to them
+func (r *ScoreRepository) ApplyCachedScore(
+	ctx context.Context, change *entity.ProcessedEvent, personalScore float64, policy *entity.RankingPolicy,
+) (bool, error) {
+	keys := []string{
+		constant.ProcessedEventPrefix + change.EventID,
+		constant.VideoRanking,
+		constant.PersonalRankingPrefix + change.UserID,
+		constant.HotVideoRanking,
+		constant.HotVideoRankingEpoch,
+		constant.HourlyVideoRankingPrefix + util.HourBucket(change.OccurredAt),
+		constant.DailyVideoRankingPrefix + util.DayBucket(change.OccurredAt),
+		constant.TrendingVideoRankingPrefix + util.HourBucket(change.OccurredAt),
+	}
+	for i := 1; i <= policy.TrendingBaselineHours; i++ {
+		keys = append(keys, constant.HourlyVideoRankingPrefix+util.HourBucket(change.OccurredAt.Add(-time.Duration(i)*time.Hour)))
+	}
+	for _, segment := range change.Segments {
+		keys = append(keys, constant.SegmentVideoRankingPrefix+segment)
+	}
+	age := time.Since(change.OccurredAt)
+	applied, err := applyCachedScoreScript.Run(ctx, r.redisClient, keys,
+		int64(policy.MarkerTTL.Seconds()), change.Delta, change.VideoID,
+		change.OccurredAt.Unix(), policy.HotHalfLife.Seconds(), time.Now().Unix(),
+		bucketTTL(policy.HourBucketTTL, age), bucketTTL(policy.DayBucketTTL, age),
+		policy.TrendingSmoothing, personalScore, policy.PersonalMaxSize, int64(policy.PersonalTTL.Seconds()),
+		len(change.Segments),
+	).Int()
+	if err != nil {
+		log.Printf("Failed to apply cached score of event %s: %v", change.EventID, err)
+		return false, err
+	}
+	return applied == 1, nil
+}
+
+// bucketTTL returns the TTL in seconds for a leaderboard bucket, or 0 if an event of that age is past retention
+func bucketTTL(retention time.Duration, age time.Duration) int64 {
+	if age >= retention {
+		return 0
+	}
+	return int64(retention.Seconds())
+}
+
+// GetHotEpoch retrieves the time hot scores are currently relative to, or the zero time if none is set
+func (r *ScoreRepository) GetHotEpoch(ctx context.Context) (time.Time, error) {
+	epoch, err := r.redisClient.Get(ctx, constant.HotVideoRankingEpoch).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		log.Printf("Failed to get hot ranking epoch: %v", err)
+		return time.Time{}, err
+	}
+	return time.Unix(epoch, 0), nil
+}
+
+// RebaseHotRanking moves the hot epoch to now and drops members whose rebased score is below minScore
+func (r *ScoreRepository) RebaseHotRanking(ctx context.Context, halfLife time.Duration, minScore float64) (int64, error) {
+	keys := []string{constant.HotVideoRanking, constant.HotVideoRankingEpoch}
+	count, err := rebaseHotRankingScript.Run(ctx, r.redisClient, keys, time.Now().Unix(), halfLife.Seconds(), minScore).Int64()
+	if err != nil {
+		log.Printf("Failed to rebase hot ranking: %v", err)
+		return 0, err
+	}
+	log.Printf("Rebased hot ranking of %d videos", count)
+	return count, nil
+}
+
+// GetTopHotVideos retrieves a page of the top videos from the hot ranking, with their stored (undecayed) scores
+func (r *ScoreRepository) GetTopHotVideos(ctx context.Context, after *entity.RankingCursor, limit int64) ([]*entity.RankedVideo, error) {
+	videos, err := r.rankingPage(ctx, constant.HotVideoRanking, after, limit)
+	if err != nil {
+		log.Printf("Failed to get top hot videos: %v", err)
+		return nil, err
+	}
+	log.Printf("Successfully retrieved top %d hot videos", limit)
+	return videos, nil
+}
+
+// GetTopWindowVideos retrieves a page of the top videos within a time window ending now.
+// 1h reads the current hourly bucket, 24h and 7d union the last 24 hourly or 7 daily buckets with
+// ZUNIONSTORE into a key cached for cacheTTL, and all reads the all-time ranking.
+func (r *ScoreRepository) GetTopWindowVideos(
+	ctx context.Context, window constant.RankingWindow, after *entity.RankingCursor, limit int64, cacheTTL time.Duration,
+) ([]*entity.RankedVideo, error) {
+	now := time.Now()
+	var key string
+	switch window {
+	case constant.WindowHour:
+		key = constant.HourlyVideoRankingPrefix + util.HourBucket(now)
+	case constant.WindowDay:
+		buckets := make([]string, 0, 24)
+		for i := 0; i < 24; i++ {
+			buckets = append(buckets, constant.HourlyVideoRankingPrefix+util.HourBucket(now.Add(-time.Duration(i)*time.Hour)))
+		}
+		key = constant.WindowVideoRankingPrefix + string(window)
+		if err := r.unionBuckets(ctx, key, buckets, cacheTTL); err != nil {
+			return nil, err
+		}
+	case constant.WindowWeek:
+		buckets := make([]string, 0, 7)
+		for i := 0; i < 7; i++ {
+			buckets = append(buckets, constant.DailyVideoRankingPrefix+util.DayBucket(now.AddDate(0, 0, -i)))
+		}
+		key = constant.WindowVideoRankingPrefix + string(window)
+		if err := r.unionBuckets(ctx, key, buckets, cacheTTL); err != nil {
+			return nil, err
+		}
+	default:
+		key = constant.VideoRanking
+	}
+
+	videos, err := r.rankingPage(ctx, key, after, limit)
+	if err != nil {
+		log.Printf("Failed to get top videos in window %s: %v", window, err)
+		return nil, err
+	}
+	log.Printf("Successfully retrieved top %d videos in window %s", limit, window)
+	return videos, nil
+}
+
+// unionBuckets aggregates bucket ZSETs into dest unless a cached aggregate still exists
+func (r *ScoreRepository) unionBuckets(ctx context.Context, dest string, buckets []string, cacheTTL time.Duration) error {
+	exists, err := r.redisClient.Exists(ctx, dest).Result()
+	if err != nil {
+		log.Printf("Failed to check window ranking %s: %v", dest, err)
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.ZUnionStore(ctx, dest, &redis.ZStore{Keys: buckets, Aggregate: "SUM"})
+	pipe.Expire(ctx, dest, cacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to aggregate window ranking %s: %v", dest, err)
 		return err
 	}
-	log.Printf("Successfully inserted personal score for user %s and video %s", userID, videoID)
 	return nil
 }
 
-// UpdateCachedScore updates the score of a video in Redis for quick ranking
-func (r *ScoreRepository) UpdateCachedScore(ctx context.Context, videoID string, score float64) error {
-	if err := r.redisClient.ZAdd(ctx, constant.VideoRanking, &redis.Z{
-		Score:  score,
-		Member: videoID,
-	}).Err(); err != nil {
-		log.Printf("Failed to cache score for video %s: %v", videoID, err)
+// GetTopSegmentVideos retrieves a page of the top videos of the intersection of segment rankings.
+// A single segment is read directly, several are intersected with ZINTERSTORE into a key cached for
+// cacheTTL. The intersection keeps each video's lowest segment score, so "category:gaming" with
+// "region:vn" scores a video by what it earned in that region.
+func (r *ScoreRepository) GetTopSegmentVideos(
+	ctx context.Context, segments []string, after *entity.RankingCursor, limit int64, cacheTTL time.Duration,
+) ([]*entity.RankedVideo, error) {
+	keys := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		keys = append(keys, constant.SegmentVideoRankingPrefix+segment)
+	}
+	key := keys[0]
+	if len(keys) > 1 {
+		key = constant.SegmentIntersectionPrefix + strings.Join(segments, "|")
+		if err := r.intersectSegments(ctx, key, keys, cacheTTL); err != nil {
+			return nil, err
+		}
+	}
+
+	videos, err := r.rankingPage(ctx, key, after, limit)
+	if err != nil {
+		log.Printf("Failed to get top videos of segments %v: %v", segments, err)
+		return nil, err
+	}
+	log.Printf("Successfully retrieved top %d videos of segments %v", limit, segments)
+	return videos, nil
+}
+
+// intersectSegments intersects segment rankings into dest unless a cached intersection still exists
+func (r *ScoreRepository) intersectSegments(ctx context.Context, dest string, segments []string, cacheTTL time.Duration) error {
+	exists, err := r.redisClient.Exists(ctx, dest).Result()
+	if err != nil {
+		log.Printf("Failed to check segment intersection %s: %v", dest, err)
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.ZInterStore(ctx, dest, &redis.ZStore{Keys: segments, Aggregate: "MIN"})
+	pipe.Expire(ctx, dest, cacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to intersect segment rankings %s: %v", dest, err)
 		return err
 	}
-	log.Printf("Cached score for video %s: %f", videoID, score)
 	return nil
 }
 
-// GetTopRankedVideos retrieves the top N videos from the Redis Sorted Set
-func (r *ScoreRepository) GetTopRankedVideos(ctx context.Context, limit int64) ([]string, error) {
-	videos, err := r.redisClient.ZRevRange(ctx, constant.VideoRanking, 0, limit-1).Result()
+// GetTrendingVideos retrieves the top N videos by velocity in the current hour.
+// Early in an hour the current ranking can be empty, the previous hour's is used then.
+func (r *ScoreRepository) GetTrendingVideos(ctx context.Context, limit int64) ([]*entity.TrendingVideo, error) {
+	now := time.Now()
+	var members []redis.Z
+	for _, hour := range []time.Time{now, now.Add(-time.Hour)} {
+		var err error
+		members, err = r.redisClient.ZRevRangeWithScores(ctx, constant.TrendingVideoRankingPrefix+util.HourBucket(hour), 0, limit-1).Result()
+		if err != nil {
+			log.Printf("Failed to get trending videos: %v", err)
+			return nil, err
+		}
+		if len(members) > 0 {
+			break
+		}
+	}
+
+	videos := make([]*entity.TrendingVideo, 0, len(members))
+	for _, member := range members {
+		videos = append(videos, &entity.TrendingVideo{
+			VideoID:  member.Member.(string),
+			Velocity: member.Score,
+		})
+	}
+	log.Printf("Successfully retrieved top %d trending videos", limit)
+	return videos, nil
+}
+
+// GetVideoStanding retrieves a video's standing in the global ranking, or nil if it is not ranked
+func (r *ScoreRepository) GetVideoStanding(ctx context.Context, videoID string, neighbors int64) (*entity.VideoStanding, error) {
+	standing, err := r.videoStanding(ctx, constant.VideoRanking, videoID, neighbors)
 	if err != nil {
-		log.Printf("Failed to get top-ranked videos: %v", err)
+		log.Printf("Failed to get standing of video %s: %v", videoID, err)
 		return nil, err
 	}
-	log.Printf("Successfully retrieved top %d ranked videos", limit)
+	return standing, nil
+}
+
+// GetPersonalVideoStanding retrieves a video's standing in a user's personal ranking, or nil if it is not ranked
+func (r *ScoreRepository) GetPersonalVideoStanding(
+	ctx context.Context, userID string, videoID string, neighbors int64,
+) (*entity.VideoStanding, error) {
+	standing, err := r.videoStanding(ctx, constant.PersonalRankingPrefix+userID, videoID, neighbors)
+	if err != nil {
+		log.Printf("Failed to get standing of video %s for user %s: %v", videoID, userID, err)
+		return nil, err
+	}
+	return standing, nil
+}
+
+// videoStanding reads a member's rank, score and the ZSET size in one round trip,
+// then the neighbors members around it if requested
+func (r *ScoreRepository) videoStanding(ctx context.Context, key string, videoID string, neighbors int64) (*entity.VideoStanding, error) {
+	pipe := r.redisClient.Pipeline()
+	rankCmd := pipe.ZRevRank(ctx, key, videoID)
+	scoreCmd := pipe.ZScore(ctx, key, videoID)
+	totalCmd := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	rank, err := rankCmd.Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	standing := &entity.VideoStanding{
+		Rank:  rank + 1,
+		Score: scoreCmd.Val(),
+		Total: totalCmd.Val(),
+	}
+	if standing.Total > 0 {
+		standing.Percentile = float64(standing.Total-rank) / float64(standing.Total) * 100
+	}
+	if neighbors <= 0 {
+		return standing, nil
+	}
+
+	if rank > 0 {
+		above, err := r.rankedRange(ctx, key, max(rank-neighbors, 0), rank-1)
+		if err != nil {
+			return nil, err
+		}
+		standing.Above = above
+	}
+	below, err := r.rankedRange(ctx, key, rank+1, rank+neighbors)
+	if err != nil {
+		return nil, err
+	}
+	standing.Below = below
+	return standing, nil
+}
+
+// rankedRange reads the members between two zero-based positions of a ZSET in descending order
+func (r *ScoreRepository) rankedRange(ctx context.Context, key string, start int64, stop int64) ([]*entity.RankedVideo, error) {
+	members, err := r.redisClient.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	videos := make([]*entity.RankedVideo, 0, len(members))
+	for i, member := range members {
+		videos = append(videos, &entity.RankedVideo{
+			Rank:    start + int64(i) + 1,
+			VideoID: member.Member.(string),
+			Score:   member.Score,
+		})
+	}
 	return videos, nil
 }
 
-// UpdatePersonalizedRankingCache updates the personalized ranking cache for a user
-func (r *ScoreRepository) UpdatePersonalizedRankingCache(ctx context.Context, userID string, videoID string, score float64) error {
-	if err := r.redisClient.ZAdd(ctx, constant.PersonalRankingPrefix+userID, &redis.Z{
-		Score:  score,
-		Member: videoID,
-	}).Err(); err != nil {
-		log.Printf("Failed to cache personalized score for user %s and video %s: %v", userID, videoID, err)
+// IsRankingEmpty reports whether the global ranking ZSET is missing
+func (r *ScoreRepository) IsRankingEmpty(ctx context.Context) (bool, error) {
+	exists, err := r.redisClient.Exists(ctx, constant.VideoRanking).Result()
+	if err != nil {
+		log.Printf("Failed to check global ranking: %v", err)
+		return false, err
+	}
+	return exists == 0, nil
+}
+
+// AcquireRebuildLease takes the ranking rebuild lease for owner if no one holds it
+func (r *ScoreRepository) AcquireRebuildLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := r.redisClient.SetNX(ctx, constant.RebuildLease, owner, ttl).Result()
+	if err != nil {
+		log.Printf("Failed to acquire rebuild lease: %v", err)
+		return false, err
+	}
+	return acquired, nil
+}
+
+// RenewRebuildLease extends the rebuild lease held by owner, reporting false if it was lost
+func (r *ScoreRepository) RenewRebuildLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	renewed, err := renewLeaseScript.Run(ctx, r.redisClient, []string{constant.RebuildLease}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		log.Printf("Failed to renew rebuild lease: %v", err)
+		return false, err
+	}
+	return renewed == 1, nil
+}
+
+// ReleaseRebuildLease gives up the rebuild lease if owner still holds it
+func (r *ScoreRepository) ReleaseRebuildLease(ctx context.Context, owner string) error {
+	if err := releaseLeaseScript.Run(ctx, r.redisClient, []string{constant.RebuildLease}, owner).Err(); err != nil {
+		log.Printf("Failed to release rebuild lease: %v", err)
 		return err
 	}
-	log.Printf("Cached personalized score for user %s and video %s: %f", userID, videoID, score)
 	return nil
 }
 
-// GetPersonalTopRankedVideos retrieves the top N personalized
-func (r *ScoreRepository) GetPersonalTopRankedVideos(ctx context.Context, userID string, limit int64) ([]string, error) {
-	videos, err := r.redisClient.ZRevRange(ctx, constant.PersonalRankingPrefix+userID, 0, limit-1).Result()
+// SaveRebuildStatus stores the status of the running or last rebuild
+func (r *ScoreRepository) SaveRebuildStatus(ctx context.Context, status *entity.RebuildStatus) error {
+	data, err := json.Marshal(status)
 	if err != nil {
-		log.Printf("Failed to get personalized top-ranked videos for user %s: %v", userID, err)
+		return err
+	}
+	if err := r.redisClient.Set(ctx, constant.RebuildStatus, data, 0).Err(); err != nil {
+		log.Printf("Failed to save rebuild status: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRebuildStatus retrieves the status of the running or last rebuild, or nil if none ran
+func (r *ScoreRepository) GetRebuildStatus(ctx context.Context) (*entity.RebuildStatus, error) {
+	data, err := r.redisClient.Get(ctx, constant.RebuildStatus).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed to get rebuild status: %v", err)
 		return nil, err
 	}
-	log.Printf("Successfully retrieved personalized top %d ranked videos for user %s", limit, userID)
+	status := &entity.RebuildStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		log.Printf("Failed to decode rebuild status: %v", err)
+		return nil, err
+	}
+	return status, nil
+}
+
+// RebuildGlobalRanking streams video_scores in batches into a temporary ZSET and renames it over
+// the global ranking, so readers never see a partial ranking. progress receives the running count
+// after every batch and stops the rebuild by returning an error. Events applied while it runs are
+// lost from the ranking until the next rebuild or reconciliation. It returns the number of videos ranked.
+func (r *ScoreRepository) RebuildGlobalRanking(ctx context.Context, batchSize int, progress func(videos int64) error) (int64, error) {
+	tmp := constant.RebuildKeyPrefix + constant.VideoRanking
+	if err := r.redisClient.Del(ctx, tmp).Err(); err != nil {
+		log.Printf("Failed to clear %s: %v", tmp, err)
+		return 0, err
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().
+		SetBatchSize(int32(batchSize)).
+		SetProjection(bson.M{"video_id": 1, "score": 1}))
+	if err != nil {
+		log.Printf("Failed to read video scores: %v", err)
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var videos int64
+	members := make([]*redis.Z, 0, batchSize)
+	flush := func() error {
+		if len(members) == 0 {
+			return nil
+		}
+		if err := r.redisClient.ZAdd(ctx, tmp, members...).Err(); err != nil {
+			log.Printf("Failed to write %s: %v", tmp, err)
+			return err
+		}
+		videos += int64(len(members))
+		members = members[:0]
+		return progress(videos)
+	}
+	for cursor.Next(ctx) {
+		var doc struct {
+			VideoID string  `bson:"video_id"`
+			Score   float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("Failed to decode video score: %v", err)
+			return videos, err
+		}
+		members = append(members, &redis.Z{Score: doc.Score, Member: doc.VideoID})
+		if len(members) == batchSize {
+			if err := flush(); err != nil {
+				return videos, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Failed to read video scores: %v", err)
+		return videos, err
+	}
+	if err := flush(); err != nil {
+		return videos, err
+	}
+
+	if videos == 0 {
+		err = r.redisClient.Del(ctx, constant.VideoRanking).Err()
+	} else {
+		err = r.redisClient.Rename(ctx, tmp, constant.VideoRanking).Err()
+	}
+	if err != nil {
+		log.Printf("Failed to replace global ranking: %v", err)
+		return videos, err
+	}
+	log.Printf("Rebuilt global ranking of %d videos", videos)
+	return videos, nil
+}
+
+// RebuildPersonalRankings streams personal_scores ordered by user into temporary ZSETs with pipelined
+// writes, renaming each user's ZSET over their personal ranking once all of their scores are written.
+// Each ranking is trimmed to maxSize and expires after ttl like a live one, 0 disabling either.
+// progress receives the running counts after every batch and stops the rebuild by returning an error.
+// It returns the number of users and entries.
+func (r *ScoreRepository) RebuildPersonalRankings(
+	ctx context.Context, batchSize int, maxSize int64, ttl time.Duration, progress func(users int64, entries int64) error,
+) (int64, int64, error) {
+	cursor, err := r.personalCollection.Find(ctx, bson.M{}, options.Find().
+		SetBatchSize(int32(batchSize)).
+		SetSort(bson.D{{Key: "user_id", Value: 1}}).
+		SetProjection(bson.M{"user_id": 1, "video_id": 1, "score": 1}))
+	if err != nil {
+		log.Printf("Failed to read personal scores: %v", err)
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var users, entries int64
+	pipe := r.redisClient.Pipeline()
+	current := ""
+	finish := func() {
+		tmp := constant.RebuildKeyPrefix + constant.PersonalRankingPrefix + current
+		if maxSize > 0 {
+			pipe.ZRemRangeByRank(ctx, tmp, 0, -maxSize-1)
+		}
+		if ttl > 0 {
+			pipe.Expire(ctx, tmp, ttl)
+		}
+		pipe.Rename(ctx, tmp, constant.PersonalRankingPrefix+current)
+		users++
+	}
+	for cursor.Next(ctx) {
+		var doc struct {
+			UserID  string  `bson:"user_id"`
+			VideoID string  `bson:"video_id"`
+			Score   float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("Failed to decode personal score: %v", err)
+			return users, entries, err
+		}
+		if doc.UserID != current {
+			if current != "" {
+				finish()
+			}
+			current = doc.UserID
+			pipe.Del(ctx, constant.RebuildKeyPrefix+constant.PersonalRankingPrefix+current)
+		}
+		pipe.ZAdd(ctx, constant.RebuildKeyPrefix+constant.PersonalRankingPrefix+current, &redis.Z{Score: doc.Score, Member: doc.VideoID})
+		entries++
+
+		if pipe.Len() >= batchSize {
+			if _, err := pipe.Exec(ctx); err != nil {
+				log.Printf("Failed to write personal rankings: %v", err)
+				return users, entries, err
+			}
+			if err := progress(users, entries); err != nil {
+				return users, entries, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Failed to read personal scores: %v", err)
+		return users, entries, err
+	}
+	if current != "" {
+		finish()
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to write personal rankings: %v", err)
+		return users, entries, err
+	}
+	if err := progress(users, entries); err != nil {
+		return users, entries, err
+	}
+
+	log.Printf("Rebuilt personal rankings of %d users with %d entries", users, entries)
+	return users, entries, nil
+}
+
+// rankingPage reads up to limit members of a ZSET in descending order, starting after the cursor if given.
+// Members sharing the cursor's score are ordered by ID, the ones not after the cursor are skipped.
+func (r *ScoreRepository) rankingPage(ctx context.Context, key string, after *entity.RankingCursor, limit int64) ([]*entity.RankedVideo, error) {
+	var members []redis.Z
+	if after == nil {
+		var err error
+		members, err = r.redisClient.ZRevRangeWithScores(ctx, key, 0, limit-1).Result()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		max := strconv.FormatFloat(after.Score, 'g', -1, 64)
+		for offset := int64(0); int64(len(members)) < limit; {
+			batch, err := r.redisClient.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+				Max:    max,
+				Min:    "-inf",
+				Offset: offset,
+				Count:  limit,
+			}).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			for _, member := range batch {
+				if member.Score == after.Score && member.Member.(string) >= after.VideoID {
+					continue
+				}
+				if int64(len(members)) < limit {
+					members = append(members, member)
+				}
+			}
+			offset += int64(len(batch))
+		}
+	}
+
+	videos := make([]*entity.RankedVideo, 0, len(members))
+	if len(members) == 0 {
+		return videos, nil
+	}
+
+	rank, err := r.redisClient.ZRevRank(ctx, key, members[0].Member.(string)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, member := range members {
+		videos = append(videos, &entity.RankedVideo{
+			Rank:    rank + int64(i) + 1,
+			VideoID: member.Member.(string),
+			Score:   member.Score,
+		})
+	}
 	return videos, nil
 }
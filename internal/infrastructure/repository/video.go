@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var VideoCollectionName = "videos"
+
+// VideoRepository keeps the video catalog in MongoDB, one document per video keyed by its ID
+type VideoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewVideoRepository initializes the repository
+func NewVideoRepository(db *mongo.Database) *VideoRepository {
+	return &VideoRepository{collection: db.Collection(VideoCollectionName)}
+}
+
+// EnsureIndexes creates the indexes used to filter the catalog by category, creator and language
+func (r *VideoRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "creator_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "language", Value: 1}, {Key: "updated_at", Value: -1}}},
+	}); err != nil {
+		log.Printf("Failed to create video indexes: %v", err)
+		return err
+	}
+	return nil
+}
+
+// UpsertVideos creates or replaces the metadata of videos in one unordered bulk write and
+// returns how many were created and how many already existed
+func (r *VideoRepository) UpsertVideos(ctx context.Context, videos []*entity.Video) (int64, int64, error) {
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(videos))
+	for _, video := range videos {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": video.VideoID}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"title":      video.Title,
+					"category":   video.Category,
+					"creator_id": video.CreatorID,
+					"language":   video.Language,
+					"status":     video.Status,
+					"updated_at": now,
+				},
+				"$setOnInsert": bson.M{"created_at": now},
+			}).
+			SetUpsert(true))
+	}
+
+	result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Printf("Failed to upsert %d videos: %v", len(videos), err)
+		return 0, 0, err
+	}
+	return result.UpsertedCount, result.MatchedCount, nil
+}
+
+// GetVideo retrieves a video by its ID
+func (r *VideoRepository) GetVideo(ctx context.Context, videoID string) (*entity.Video, error) {
+	var video entity.Video
+	if err := r.collection.FindOne(ctx, bson.M{"_id": videoID}).Decode(&video); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to get video %s: %v", videoID, err)
+		}
+		return nil, err
+	}
+	return &video, nil
+}
+
+// GetVideos retrieves the catalog entries of videoIDs by ID, videos missing from the catalog are left out
+func (r *VideoRepository) GetVideos(ctx context.Context, videoIDs []string) (map[string]*entity.Video, error) {
+	videos := make(map[string]*entity.Video, len(videoIDs))
+	if len(videoIDs) == 0 {
+		return videos, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": videoIDs}})
+	if err != nil {
+		log.Printf("Failed to get %d videos: %v", len(videoIDs), err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var video entity.Video
+		if err := cursor.Decode(&video); err != nil {
+			log.Printf("Failed to decode video: %v", err)
+			return nil, err
+		}
+		videos[video.VideoID] = &video
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Failed to read videos: %v", err)
+		return nil, err
+	}
+	return videos, nil
+}
+
+// ListVideos retrieves the videos matching filter, most recently updated first
+func (r *VideoRepository) ListVideos(
+	ctx context.Context, filter entity.VideoFilter, limit int64, offset int64,
+) ([]*entity.Video, error) {
+	query := bson.M{}
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+	if filter.CreatorID != "" {
+		query["creator_id"] = filter.CreatorID
+	}
+	if filter.Language != "" {
+		query["language"] = filter.Language
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: 1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		log.Printf("Failed to list videos: %v", err)
+		return nil, err
+	}
+
+	videos := []*entity.Video{}
+	if err := cursor.All(ctx, &videos); err != nil {
+		log.Printf("Failed to decode videos: %v", err)
+		return nil, err
+	}
+	return videos, nil
+}
+
+// DeleteVideo removes a video from the catalog
+func (r *VideoRepository) DeleteVideo(ctx context.Context, videoID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": videoID})
+	if err != nil {
+		log.Printf("Failed to delete video %s: %v", videoID, err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+)
+
+// WeightRepository reads the interaction weight table from a JSON file, an inline
+// "type=weight,..." list or, when neither is configured, the built-in defaults.
+// The file is read again on every Load, so edits are picked up by the reloader.
+type WeightRepository struct {
+	file   string
+	inline string
+}
+
+// NewWeightRepository initializes the repository
+func NewWeightRepository(file string, inline string) *WeightRepository {
+	return &WeightRepository{file: file, inline: inline}
+}
+
+// Load reads the current weight table
+func (r *WeightRepository) Load() (*entity.WeightTable, error) {
+	switch {
+	case r.file != "":
+		data, err := os.ReadFile(r.file)
+		if err != nil {
+			log.Printf("Failed to read weights file %s: %v", r.file, err)
+			return nil, err
+		}
+		weights := map[constant.InteractionType]float64{}
+		if err := json.Unmarshal(data, &weights); err != nil {
+			log.Printf("Failed to parse weights file %s: %v", r.file, err)
+			return nil, err
+		}
+		return &entity.WeightTable{Weights: weights, Source: "file:" + r.file}, nil
+	case r.inline != "":
+		weights, err := parseInlineWeights(r.inline)
+		if err != nil {
+			log.Printf("Failed to parse inline weights: %v", err)
+			return nil, err
+		}
+		return &entity.WeightTable{Weights: weights, Source: "env"}, nil
+	default:
+		weights := make(map[constant.InteractionType]float64, len(constant.DefaultInteractionWeights))
+		for interactionType, weight := range constant.DefaultInteractionWeights {
+			weights[interactionType] = weight
+		}
+		return &entity.WeightTable{Weights: weights, Source: "default"}, nil
+	}
+}
+
+// parseInlineWeights parses a "view=1,like=2" list
+func parseInlineWeights(inline string) (map[constant.InteractionType]float64, error) {
+	weights := map[constant.InteractionType]float64{}
+	for _, pair := range strings.Split(inline, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, expected type=weight", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for %q: %w", name, err)
+		}
+		weights[constant.InteractionType(strings.TrimSpace(name))] = weight
+	}
+	return weights, nil
+}
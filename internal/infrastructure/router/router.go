@@ -1,6 +1,8 @@
 package router
 
 import (
+	"strings"
+
 	"go-server/internal/api/handler"
 	"go-server/internal/docs"
 
@@ -20,17 +22,78 @@ func Initialize(h handler.AppHandler) {
 		interactionGroup := appVersion1Group.Group("interactions")
 		{
 			interactionGroup.POST("/:video_id", h.InteractionHandler.CreateNewInteraction)
+			interactionGroup.DELETE("/:event_id", h.InteractionHandler.RetractInteraction)
 		}
+		appVersion1Group.POST("interactions:method", customMethods("method", map[string]gin.HandlerFunc{
+			"batchCreate": h.InteractionHandler.CreateInteractions,
+		}))
 		rankingGroup := appVersion1Group.Group("rankings")
 		{
 			rankingGroup.GET("", h.ScoreHandler.GetGlobalRanking)
+			rankingGroup.GET("/trending", h.ScoreHandler.GetTrendingRanking)
+			rankingGroup.GET("/videos/:video_id", h.ScoreHandler.GetVideoRanking)
 			rankingGroup.GET("/:user_id", h.ScoreHandler.GetPersonalRanking)
 		}
+		appVersion1Group.POST("scores:method", customMethods("method", map[string]gin.HandlerFunc{
+			"batchGet": h.ScoreHandler.BatchGetScores,
+		}))
+		appVersion1Group.GET("videos/:video_id/related", h.RelatedHandler.GetRelatedVideos)
+		appVersion1Group.GET("users/:user_id/related", h.RelatedHandler.GetRecommendedVideos)
+		adminGroup := appVersion1Group.Group("admin")
+		{
+			deadLetterGroup := adminGroup.Group("dead-letters")
+			{
+				deadLetterGroup.GET("", h.DeadLetterHandler.ListDeadLetters)
+				deadLetterGroup.GET("/:id", h.DeadLetterHandler.GetDeadLetter)
+				deadLetterGroup.POST("/:id/redrive", h.DeadLetterHandler.RedriveDeadLetter)
+				deadLetterGroup.DELETE("/:id", h.DeadLetterHandler.DiscardDeadLetter)
+			}
+			adminGroup.GET("weights", h.WeightHandler.GetWeights)
+			quarantineGroup := adminGroup.Group("quarantine")
+			{
+				quarantineGroup.GET("", h.FraudHandler.ListQuarantined)
+				quarantineGroup.POST("/:id/release", h.FraudHandler.ReleaseQuarantined)
+				quarantineGroup.DELETE("/:id", h.FraudHandler.PurgeQuarantined)
+			}
+			adminGroup.POST("rankings/rebuild", h.RebuildHandler.StartRebuild)
+			adminGroup.GET("rankings/rebuild", h.RebuildHandler.GetRebuildStatus)
+			adminGroup.GET("rankings/reconciliation", h.ReconcileHandler.GetReconcileStats)
+			flaggedUserGroup := adminGroup.Group("flagged-users")
+			{
+				flaggedUserGroup.GET("", h.FraudHandler.ListFlaggedUsers)
+				flaggedUserGroup.PUT("/:user_id", h.FraudHandler.FlagUser)
+				flaggedUserGroup.DELETE("/:user_id", h.FraudHandler.UnflagUser)
+				flaggedUserGroup.POST("/:user_id/subtract", h.FraudHandler.SubtractUserContribution)
+			}
+			videoGroup := adminGroup.Group("videos")
+			{
+				videoGroup.GET("", h.VideoHandler.ListVideos)
+				videoGroup.GET("/:video_id", h.VideoHandler.GetVideo)
+				videoGroup.PUT("/:video_id", h.VideoHandler.UpsertVideo)
+				videoGroup.DELETE("/:video_id", h.VideoHandler.DeleteVideo)
+			}
+			adminGroup.POST("videos:method", customMethods("method", map[string]gin.HandlerFunc{
+				"batchUpsert": h.VideoHandler.UpsertVideos,
+			}))
+		}
 	}
 
 	router.Run(":8080")
 }
 
+// customMethods routes custom methods such as /scores:batchGet. Gin parses the ":method" suffix
+// as a path parameter, whose value keeps the leading colon.
+func customMethods(param string, handlers map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handler, ok := handlers[strings.TrimPrefix(c.Param(param), ":")]
+		if !ok {
+			c.AbortWithStatus(404)
+			return
+		}
+		handler(c)
+	}
+}
+
 func configSwagger(c *gin.Context) {
 	docs.SwaggerInfo.Host = c.Request.Host
 }
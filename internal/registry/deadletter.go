@@ -0,0 +1,19 @@
+package registry
+
+import (
+	"go-server/internal/api/handler"
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/deadletter"
+)
+
+func (i *interactor) NewDeadLetterRepository() *repository.DeadLetterRepository {
+	return repository.NewDeadLetterRepository(i.mongo)
+}
+
+func (i *interactor) NewDeadLetterService() deadletter.UseCase {
+	return deadletter.NewService(i.NewDeadLetterRepository())
+}
+
+func (i *interactor) NewDeadLetterHandler() handler.DeadLetterHandler {
+	return handler.NewDeadLetterHandler(i.NewDeadLetterService())
+}
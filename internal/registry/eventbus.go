@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"log"
+	"time"
+
+	"go-server/internal/infrastructure/eventbus"
+	"go-server/internal/usecase/event"
+)
+
+// NewEventBus returns the event bus selected by EVENT_BUS_DRIVER.
+// The bus is shared so that publisher and subscriber see the same in-process queue.
+func (i *interactor) NewEventBus() event.EventBus {
+	if i.eventBus != nil {
+		return i.eventBus
+	}
+
+	switch i.cfg.EventBus.Driver {
+	case eventbus.DriverMemory:
+		i.eventBus = eventbus.NewMemoryBus(i.NewDeadLetterRepository(), eventbus.MemoryOptions{
+			BufferSize: i.cfg.EventBus.BufferSize,
+			MaxRetries: i.cfg.EventBus.MaxRetries,
+			Backoff:    i.cfg.EventBus.Backoff,
+		})
+	case eventbus.DriverKafka:
+		i.eventBus = eventbus.NewKafkaBus(i.NewDeadLetterRepository(), eventbus.KafkaOptions{
+			Brokers:    i.cfg.Kafka.Brokers,
+			Topic:      i.cfg.Kafka.Topic,
+			GroupID:    i.cfg.Kafka.GroupID,
+			MaxRetries: i.cfg.EventBus.MaxRetries,
+			Backoff:    i.cfg.EventBus.Backoff,
+		})
+	case eventbus.DriverRedis:
+		i.eventBus = eventbus.NewRedisStream(i.redis, i.NewDeadLetterRepository(), eventbus.RedisOptions{
+			Name:          i.cfg.Stream.Name,
+			Group:         i.cfg.Stream.Group,
+			Consumer:      i.cfg.Stream.Consumer,
+			MaxLen:        i.cfg.Stream.MaxLen,
+			BatchSize:     i.cfg.Stream.BatchSize,
+			Block:         i.cfg.Stream.Block,
+			ClaimMinIdle:  i.cfg.Stream.ClaimMinIdle,
+			ClaimInterval: i.cfg.Stream.ClaimInterval,
+			MaxDeliveries: i.cfg.Stream.MaxDeliveries,
+			Backoff:       i.cfg.EventBus.Backoff,
+		})
+	default:
+		log.Fatalf("Unknown event bus driver %q", i.cfg.EventBus.Driver)
+	}
+
+	log.Printf("Using %s event bus", i.cfg.EventBus.Driver)
+	return i.eventBus
+}
+
+// redeliveryWindow is how long the selected event bus keeps redelivering a failing event before it
+// dead-letters it: the Redis Stream reclaims it every ClaimMinIdle up to MaxDeliveries times, the
+// other drivers, and the Redis Stream without reclaiming, retry in place with a doubling backoff
+func (i *interactor) redeliveryWindow() time.Duration {
+	if i.cfg.EventBus.Driver != eventbus.DriverRedis {
+		return i.cfg.EventBus.Backoff * time.Duration(1<<(i.cfg.EventBus.MaxRetries+1)-1)
+	}
+	if i.cfg.Stream.ClaimInterval <= 0 {
+		return i.cfg.EventBus.Backoff * time.Duration(1<<max(i.cfg.Stream.MaxDeliveries, 1)-1)
+	}
+	return i.cfg.Stream.ClaimMinIdle*time.Duration(i.cfg.Stream.MaxDeliveries) + i.cfg.Stream.ClaimInterval
+}
@@ -0,0 +1,31 @@
+package registry
+
+import (
+	"go-server/internal/api/handler"
+	"go-server/internal/entity"
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/fraud"
+)
+
+func (i *interactor) NewFraudRepository() *repository.FraudRepository {
+	return repository.NewFraudRepository(i.mongo, i.redis)
+}
+
+func (i *interactor) NewFraudService() fraud.UseCase {
+	return fraud.NewService(i.NewFraudRepository(), i.NewScoreRepository(), i.NewEventBus(), fraud.Options{
+		BurstLimit:          i.cfg.Fraud.BurstLimit,
+		BurstWindow:         i.cfg.Fraud.BurstWindow,
+		FingerprintMaxUsers: i.cfg.Fraud.FingerprintMaxUsers,
+		FingerprintWindow:   i.cfg.Fraud.FingerprintWindow,
+		RequireView:         i.cfg.Fraud.RequireView,
+		Ranking: entity.RankingPolicy{
+			HotHalfLife:           i.cfg.Hot.HalfLife,
+			TrendingBaselineHours: i.cfg.Trending.BaselineHours,
+			TrendingSmoothing:     i.cfg.Trending.Smoothing,
+		},
+	})
+}
+
+func (i *interactor) NewFraudHandler() handler.FraudHandler {
+	return handler.NewFraudHandler(i.NewFraudService())
+}
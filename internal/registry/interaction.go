@@ -1,7 +1,10 @@
 package registry
 
 import (
+	"log"
+
 	"go-server/internal/api/handler"
+	"go-server/internal/common/constant"
 	"go-server/internal/infrastructure/repository"
 	"go-server/internal/usecase/interaction"
 )
@@ -10,8 +13,38 @@ func (i *interactor) NewInteractionRepository() *repository.InteractionRepositor
 	return repository.NewInteractionRepository(i.mongo)
 }
 
-func (i *interactor) NewInteractionService() *interaction.Service {
-	return interaction.NewService(i.NewInteractionRepository(), i.redis)
+func (i *interactor) NewIdempotencyRepository() *repository.IdempotencyRepository {
+	return repository.NewIdempotencyRepository(i.redis)
+}
+
+func (i *interactor) NewCounterRepository() *repository.CounterRepository {
+	return repository.NewCounterRepository(i.redis)
+}
+
+func (i *interactor) NewAuditRepository() *repository.AuditRepository {
+	return repository.NewAuditRepository(i.mongo)
+}
+
+func (i *interactor) NewInteractionService() interaction.UseCase {
+	rules, err := repository.LoadInteractionRules(i.cfg.Rules.File)
+	if err != nil {
+		log.Fatalf("Failed to load interaction rules: %v", err)
+	}
+	validation := constant.VideoValidation(i.cfg.Video.Validation)
+	switch validation {
+	case constant.VideoValidationOff, constant.VideoValidationKnown, constant.VideoValidationPublished:
+	default:
+		log.Fatalf("Invalid VIDEO_VALIDATION %q, expected off, known or published", validation)
+	}
+	return interaction.NewService(
+		i.NewInteractionRepository(), i.NewIdempotencyRepository(), i.NewCounterRepository(), i.NewAuditRepository(),
+		i.NewVideoRepository(), i.NewWeightService(), interaction.Options{
+			IdempotencyTTL:     i.cfg.Idempotency.TTL,
+			IdempotencyLockTTL: i.cfg.Idempotency.LockTTL,
+			Rules:              rules,
+			VideoValidation:    validation,
+		},
+	)
 }
 
 func (i *interactor) NewInteractionHandler() handler.InteractionHandler {
@@ -0,0 +1,21 @@
+package registry
+
+import (
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/outbox"
+)
+
+func (i *interactor) NewOutboxRepository() *repository.OutboxRepository {
+	return repository.NewOutboxRepository(i.mongo)
+}
+
+func (i *interactor) NewOutboxRelayService() outbox.UseCase {
+	return outbox.NewRelayService(i.NewOutboxRepository(), i.NewEventBus(), outbox.Options{
+		BatchSize:     i.cfg.Outbox.BatchSize,
+		PollInterval:  i.cfg.Outbox.PollInterval,
+		Lease:         i.cfg.Outbox.Lease,
+		BaseBackoff:   i.cfg.Outbox.BaseBackoff,
+		MaxBackoff:    i.cfg.Outbox.MaxBackoff,
+		SentRetention: i.cfg.Outbox.SentRetention,
+	})
+}
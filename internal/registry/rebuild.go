@@ -0,0 +1,29 @@
+package registry
+
+import (
+	"log"
+
+	"go-server/internal/api/handler"
+	"go-server/internal/common/constant"
+	"go-server/internal/usecase/rebuild"
+)
+
+func (i *interactor) NewRebuildService() rebuild.UseCase {
+	mode := constant.RebuildMode(i.cfg.Rebuild.OnStartup)
+	switch mode {
+	case constant.RebuildNever, constant.RebuildIfEmpty, constant.RebuildAlways:
+	default:
+		log.Fatalf("Invalid REBUILD_ON_STARTUP %q, expected never, if_empty or always", mode)
+	}
+	return rebuild.NewService(i.NewScoreRepository(), rebuild.Options{
+		BatchSize:       i.cfg.Rebuild.BatchSize,
+		OnStartup:       mode,
+		Lease:           i.cfg.Rebuild.Lease,
+		PersonalMaxSize: i.cfg.Personal.MaxSize,
+		PersonalTTL:     i.cfg.Personal.IdleTTL,
+	})
+}
+
+func (i *interactor) NewRebuildHandler() handler.RebuildHandler {
+	return handler.NewRebuildHandler(i.NewRebuildService())
+}
@@ -0,0 +1,23 @@
+package registry
+
+import (
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/recompute"
+	"go-server/internal/usecase/weight"
+)
+
+func (i *interactor) NewRecomputeService() recompute.UseCase {
+	return recompute.NewService(i.NewScoreRepository(), i.NewInteractionRepository(), i.NewFraudRepository(), i.NewRebuildService(), recompute.Options{
+		BatchSize: i.cfg.Recompute.BatchSize,
+		ReportTop: i.cfg.Recompute.ReportTop,
+	})
+}
+
+// LoadWeightTable loads a fixed weight table from a JSON file, to recompute scores with weights other than the active ones
+func (i *interactor) LoadWeightTable(file string) (weight.UseCase, error) {
+	weights, err := weight.NewService(repository.NewWeightRepository(file, ""), 0)
+	if err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
@@ -0,0 +1,25 @@
+package registry
+
+import (
+	"go-server/internal/api/handler"
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/reconcile"
+)
+
+func (i *interactor) NewReconcileRepository() *repository.ReconcileRepository {
+	return repository.NewReconcileRepository(i.mongo, i.redis)
+}
+
+func (i *interactor) NewReconcileService() reconcile.UseCase {
+	return reconcile.NewService(i.NewReconcileRepository(), reconcile.Options{
+		Interval:      i.cfg.Reconcile.Interval,
+		BatchSize:     i.cfg.Reconcile.BatchSize,
+		ConfirmDelay:  i.cfg.Reconcile.ConfirmDelay,
+		Tolerance:     i.cfg.Reconcile.Tolerance,
+		PendingWindow: i.redeliveryWindow(),
+	})
+}
+
+func (i *interactor) NewReconcileHandler() handler.ReconcileHandler {
+	return handler.NewReconcileHandler(i.NewReconcileService())
+}
@@ -1,7 +1,18 @@
 package registry
 
 import (
+	"go-server/config"
 	"go-server/internal/api/handler"
+	"go-server/internal/usecase/deadletter"
+	"go-server/internal/usecase/event"
+	"go-server/internal/usecase/interaction"
+	"go-server/internal/usecase/outbox"
+	"go-server/internal/usecase/rebuild"
+	"go-server/internal/usecase/recompute"
+	"go-server/internal/usecase/reconcile"
+	"go-server/internal/usecase/related"
+	"go-server/internal/usecase/video"
+	"go-server/internal/usecase/weight"
 	"go-server/pkg/mongo"
 
 	"github.com/go-redis/redis/v8"
@@ -10,22 +21,43 @@ import (
 type interactor struct {
 	mongo mongo.MongoDB
 	redis *redis.Client
+	cfg   config.Config
+
+	eventBus event.EventBus
+	weights  weight.UseCase
 }
 
 // Interactor Interactor interface
 type Interactor interface {
 	NewAppHandler() handler.AppHandler
 	NewScoreHandler() handler.ScoreHandler
+	NewInteractionService() interaction.UseCase
+	NewOutboxRelayService() outbox.UseCase
+	NewDeadLetterService() deadletter.UseCase
+	NewWeightService() weight.UseCase
+	NewRebuildService() rebuild.UseCase
+	NewRecomputeService() recompute.UseCase
+	NewReconcileService() reconcile.UseCase
+	NewRelatedService() related.UseCase
+	NewVideoService() video.UseCase
+	LoadWeightTable(file string) (weight.UseCase, error)
 }
 
 // NewInteractor Constructs new interactor
-func NewInteractor(mg mongo.MongoDB, redisClient *redis.Client) Interactor {
-	return &interactor{mongo: mg, redis: redisClient}
+func NewInteractor(mg mongo.MongoDB, redisClient *redis.Client, cfg config.Config) Interactor {
+	return &interactor{mongo: mg, redis: redisClient, cfg: cfg}
 }
 
 func (i *interactor) NewAppHandler() handler.AppHandler {
 	return handler.AppHandler{
 		InteractionHandler: i.NewInteractionHandler(),
 		ScoreHandler:       i.NewScoreHandler(),
+		DeadLetterHandler:  i.NewDeadLetterHandler(),
+		WeightHandler:      i.NewWeightHandler(),
+		FraudHandler:       i.NewFraudHandler(),
+		RebuildHandler:     i.NewRebuildHandler(),
+		ReconcileHandler:   i.NewReconcileHandler(),
+		RelatedHandler:     i.NewRelatedHandler(),
+		VideoHandler:       i.NewVideoHandler(),
 	}
 }
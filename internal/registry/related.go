@@ -0,0 +1,28 @@
+package registry
+
+import (
+	"go-server/internal/api/handler"
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/related"
+)
+
+func (i *interactor) NewRelatedRepository() *repository.RelatedRepository {
+	return repository.NewRelatedRepository(i.mongo, i.redis)
+}
+
+func (i *interactor) NewRelatedService() related.UseCase {
+	return related.NewService(i.NewRelatedRepository(), i.NewScoreRepository(), related.Options{
+		Interval:         i.cfg.Related.Interval,
+		TTL:              i.cfg.Related.TTL,
+		BatchSize:        i.cfg.Related.BatchSize,
+		TopK:             i.cfg.Related.TopK,
+		MinSupport:       i.cfg.Related.MinSupport,
+		MaxVideosPerUser: i.cfg.Related.MaxVideosPerUser,
+		MaxPairsPerVideo: i.cfg.Related.MaxPairsPerVideo,
+		RecentVideos:     i.cfg.Related.RecentVideos,
+	})
+}
+
+func (i *interactor) NewRelatedHandler() handler.RelatedHandler {
+	return handler.NewRelatedHandler(i.NewRelatedService())
+}
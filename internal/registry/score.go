@@ -1,7 +1,11 @@
 package registry
 
 import (
+	"log"
+	"strings"
+
 	"go-server/internal/api/handler"
+	"go-server/internal/common/constant"
 	"go-server/internal/infrastructure/repository"
 	"go-server/internal/usecase/score"
 )
@@ -11,7 +15,40 @@ func (i *interactor) NewScoreRepository() *repository.ScoreRepository {
 }
 
 func (i *interactor) NewScoreService() *score.ScoreService {
-	return score.NewScoreService(i.NewScoreRepository(), i.redis)
+	dimensions := make([]constant.SegmentDimension, 0, len(i.cfg.Segment.Dimensions))
+	for _, name := range i.cfg.Segment.Dimensions {
+		dimension := constant.SegmentDimension(strings.TrimSpace(name))
+		switch dimension {
+		case "":
+			continue
+		case constant.SegmentCategory, constant.SegmentCreator, constant.SegmentLanguage, constant.SegmentRegion:
+			dimensions = append(dimensions, dimension)
+		default:
+			log.Fatalf("Invalid segment dimension %q in SEGMENT_DIMENSIONS", dimension)
+		}
+	}
+
+	return score.NewScoreService(i.NewScoreRepository(), i.NewEventBus(), i.NewWeightService(), i.NewFraudService(),
+		i.NewVideoRepository(), score.Options{
+			ProcessedEventTTL:     i.cfg.Idempotency.ProcessedEventTTL,
+			HotHalfLife:           i.cfg.Hot.HalfLife,
+			HotRebaseInterval:     i.cfg.Hot.RebaseInterval,
+			HotRebaseAfter:        i.cfg.Hot.RebaseAfter,
+			HotMinScore:           i.cfg.Hot.MinScore,
+			HourBucketTTL:         i.cfg.Window.HourBucketTTL,
+			DayBucketTTL:          i.cfg.Window.DayBucketTTL,
+			WindowCacheTTL:        i.cfg.Window.CacheTTL,
+			TrendingBaselineHours: i.cfg.Trending.BaselineHours,
+			TrendingSmoothing:     i.cfg.Trending.Smoothing,
+			PersonalMaxSize:       i.cfg.Personal.MaxSize,
+			PersonalTTL:           i.cfg.Personal.IdleTTL,
+			BlendPersonalWeight:   i.cfg.Blend.PersonalWeight,
+			BlendGlobalWeight:     i.cfg.Blend.GlobalWeight,
+			BlendTrendingWeight:   i.cfg.Blend.TrendingWeight,
+			BlendCandidates:       i.cfg.Blend.Candidates,
+			SegmentDimensions:     dimensions,
+			SegmentCacheTTL:       i.cfg.Segment.CacheTTL,
+		})
 }
 
 func (i *interactor) NewScoreHandler() handler.ScoreHandler {
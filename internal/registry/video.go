@@ -0,0 +1,19 @@
+package registry
+
+import (
+	"go-server/internal/api/handler"
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/video"
+)
+
+func (i *interactor) NewVideoRepository() *repository.VideoRepository {
+	return repository.NewVideoRepository(i.mongo)
+}
+
+func (i *interactor) NewVideoService() video.UseCase {
+	return video.NewService(i.NewVideoRepository())
+}
+
+func (i *interactor) NewVideoHandler() handler.VideoHandler {
+	return handler.NewVideoHandler(i.NewVideoService())
+}
@@ -0,0 +1,31 @@
+package registry
+
+import (
+	"log"
+
+	"go-server/internal/api/handler"
+	"go-server/internal/infrastructure/repository"
+	"go-server/internal/usecase/weight"
+)
+
+func (i *interactor) NewWeightRepository() *repository.WeightRepository {
+	return repository.NewWeightRepository(i.cfg.Weights.File, i.cfg.Weights.Inline)
+}
+
+// NewWeightService returns the shared weight registry so every use case sees the same reloaded table
+func (i *interactor) NewWeightService() weight.UseCase {
+	if i.weights != nil {
+		return i.weights
+	}
+
+	weights, err := weight.NewService(i.NewWeightRepository(), i.cfg.Weights.ReloadInterval)
+	if err != nil {
+		log.Fatalf("Failed to load interaction weights: %v", err)
+	}
+	i.weights = weights
+	return i.weights
+}
+
+func (i *interactor) NewWeightHandler() handler.WeightHandler {
+	return handler.NewWeightHandler(i.NewWeightService())
+}
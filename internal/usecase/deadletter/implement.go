@@ -0,0 +1,108 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNotFound is returned when no dead letter has the given ID
+	ErrNotFound = errors.New("dead letter not found")
+	// ErrUndecodable is returned when re-driving a dead letter whose payload is not a valid event
+	ErrUndecodable = errors.New("dead letter payload is not a valid interaction event")
+)
+
+// Service handles dead letter inspection and replay
+type Service struct {
+	repo Repository
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// ListDeadLetters retrieves dead letters, newest first
+func (s *Service) ListDeadLetters(ctx context.Context, limit int, offset int) ([]*entity.DeadLetter, error) {
+	letters, err := s.repo.List(ctx, int64(limit), int64(offset))
+	if err != nil {
+		log.Printf("[ListDeadLetters] - [List] - %v", err)
+		return nil, err
+	}
+	return letters, nil
+}
+
+// GetDeadLetter retrieves a single dead letter
+func (s *Service) GetDeadLetter(ctx context.Context, id string) (*entity.DeadLetter, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	letter, err := s.repo.GetByID(ctx, objectID)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[GetDeadLetter] - [GetByID] - %v", err)
+		return nil, err
+	}
+	return letter, nil
+}
+
+// RedriveDeadLetter hands the original event back to the outbox and removes the dead letter, so the
+// relay of a running server publishes it again whichever process re-drives it. The event keeps its ID,
+// so the score consumer ignores it if it was applied in the meantime.
+func (s *Service) RedriveDeadLetter(ctx context.Context, id string) error {
+	letter, err := s.GetDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var evt entity.InteractionEvent
+	if err := json.Unmarshal([]byte(letter.Payload), &evt); err != nil {
+		log.Printf("[RedriveDeadLetter] - [Unmarshal] - %v", err)
+		return ErrUndecodable
+	}
+
+	err = s.repo.RedriveWithOutbox(ctx, letter.ID, &evt)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[RedriveDeadLetter] - [RedriveWithOutbox] - %v", err)
+		return err
+	}
+
+	log.Printf("[RedriveDeadLetter] - Re-drove dead letter %s", id)
+	return nil
+}
+
+// DiscardDeadLetter removes a dead letter without replaying it
+func (s *Service) DiscardDeadLetter(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	err = s.repo.Delete(ctx, objectID)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[DiscardDeadLetter] - [Delete] - %v", err)
+		return err
+	}
+
+	log.Printf("[DiscardDeadLetter] - Discarded dead letter %s", id)
+	return nil
+}
@@ -0,0 +1,27 @@
+package deadletter
+
+import (
+	"context"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Action interface {
+	List(ctx context.Context, limit int64, offset int64) ([]*entity.DeadLetter, error)
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.DeadLetter, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	RedriveWithOutbox(ctx context.Context, id primitive.ObjectID, event *entity.InteractionEvent) error
+}
+
+type Repository interface {
+	Action
+}
+
+type UseCase interface {
+	ListDeadLetters(ctx context.Context, limit int, offset int) ([]*entity.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id string) (*entity.DeadLetter, error)
+	RedriveDeadLetter(ctx context.Context, id string) error
+	DiscardDeadLetter(ctx context.Context, id string) error
+}
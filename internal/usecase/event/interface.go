@@ -0,0 +1,34 @@
+package event
+
+import (
+	"context"
+
+	"go-server/internal/entity"
+)
+
+// Handler processes a single interaction event. A returned error asks the
+// subscriber to deliver the event again.
+type Handler func(ctx context.Context, event *entity.InteractionEvent) error
+
+// EventPublisher delivers interaction events to the score consumer
+type EventPublisher interface {
+	Publish(ctx context.Context, event *entity.InteractionEvent) error
+	// PublishBatch delivers several events in one round trip. On error some of them may
+	// have been delivered already, consumers must tolerate duplicates.
+	PublishBatch(ctx context.Context, events []*entity.InteractionEvent) error
+}
+
+// EventSubscriber delivers interaction events to a handler until ctx is done
+type EventSubscriber interface {
+	Subscribe(ctx context.Context, handler Handler) error
+}
+
+type EventBus interface {
+	EventPublisher
+	EventSubscriber
+}
+
+// DeadLetterStore keeps events the subscriber gave up on, so they can be inspected and re-driven
+type DeadLetterStore interface {
+	Insert(ctx context.Context, letter *entity.DeadLetter) error
+}
@@ -0,0 +1,284 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNotFound is returned when no quarantined event has the given ID
+	ErrNotFound = errors.New("quarantined event not found")
+	// ErrUserNotFlagged is returned when a flagged user operation targets a user that is not flagged
+	ErrUserNotFlagged = errors.New("user is not flagged")
+)
+
+// Options holds the thresholds of the fraud detectors, a zero limit or a window under a second disables a detector
+type Options struct {
+	// BurstLimit is the number of events a user may send within BurstWindow before being flagged
+	BurstLimit  int64
+	BurstWindow time.Duration
+	// FingerprintMaxUsers is the number of distinct users a fingerprint may carry within
+	// FingerprintWindow before the users sending from it are flagged
+	FingerprintMaxUsers int64
+	FingerprintWindow   time.Duration
+	// RequireView quarantines interactions other than views on videos without any counted view
+	RequireView bool
+	// Ranking describes how events were applied to the rankings, so a flagged user's events can be taken out
+	Ranking entity.RankingPolicy
+}
+
+// Service screens interaction events for fraud and manages the quarantine
+type Service struct {
+	repo          Repository
+	contributions ContributionStore
+	publisher     event.EventPublisher
+	opts          Options
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository, contributions ContributionStore, publisher event.EventPublisher, opts Options) *Service {
+	return &Service{
+		repo:          r,
+		contributions: contributions,
+		publisher:     publisher,
+		opts:          opts,
+	}
+}
+
+// EnsureIndexes prepares the quarantine collection
+func (s *Service) EnsureIndexes(ctx context.Context) error {
+	return s.repo.EnsureIndexes(ctx)
+}
+
+// Screen runs the fraud detectors on an event before it is scored and returns true if the event
+// must not be applied. Suspicious events are quarantined, and bursts or shared fingerprints also
+// flag the user so all of their later events are quarantined. Events an admin released pass.
+// A retraction of a quarantined event removes it from the quarantine and is itself not applied.
+func (s *Service) Screen(ctx context.Context, evt *entity.InteractionEvent) (bool, error) {
+	if evt.RetractsEventID != "" {
+		dropped, err := s.repo.DeleteHeld(ctx, evt.RetractsEventID)
+		if err != nil {
+			log.Printf("[Screen] - [DeleteHeld] - %v", err)
+			return false, err
+		}
+		return dropped, nil
+	}
+
+	reason, err := s.detect(ctx, evt)
+	if err != nil || reason == "" {
+		return false, err
+	}
+
+	released, err := s.repo.IsReleased(ctx, evt.EventID)
+	if err != nil {
+		log.Printf("[Screen] - [IsReleased] - %v", err)
+		return false, err
+	}
+	if released {
+		return false, nil
+	}
+
+	if err := s.repo.Quarantine(ctx, &entity.QuarantinedEvent{
+		Event:     *evt,
+		Reason:    reason,
+		Status:    entity.QuarantineHeld,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		log.Printf("[Screen] - [Quarantine] - %v", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// detect returns why an event is suspicious, or an empty string if it is not. The counters are bucketed
+// by the event's time and count each event ID once, so screening a redelivered event again is harmless.
+func (s *Service) detect(ctx context.Context, evt *entity.InteractionEvent) (string, error) {
+	at := evt.OccurredAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	flagged, err := s.repo.GetFlaggedUser(ctx, evt.UserID)
+	if err != nil {
+		log.Printf("[detect] - [GetFlaggedUser] - %v", err)
+		return "", err
+	}
+	if flagged != nil {
+		return "user is flagged: " + flagged.Reason, nil
+	}
+
+	if s.opts.BurstLimit > 0 && s.opts.BurstWindow >= time.Second {
+		count, err := s.repo.CountUserEvent(ctx, evt.UserID, evt.EventID, at, s.opts.BurstWindow)
+		if err != nil {
+			log.Printf("[detect] - [CountUserEvent] - %v", err)
+			return "", err
+		}
+		if count > s.opts.BurstLimit {
+			return s.flag(ctx, evt.UserID, fmt.Sprintf("burst of %d interactions within %s", count, s.opts.BurstWindow))
+		}
+	}
+
+	if s.opts.FingerprintMaxUsers > 0 && s.opts.FingerprintWindow >= time.Second && evt.Fingerprint != "" {
+		users, err := s.repo.AddFingerprintUser(ctx, evt.Fingerprint, evt.UserID, at, s.opts.FingerprintWindow)
+		if err != nil {
+			log.Printf("[detect] - [AddFingerprintUser] - %v", err)
+			return "", err
+		}
+		if users > s.opts.FingerprintMaxUsers {
+			return s.flag(ctx, evt.UserID, fmt.Sprintf("fingerprint %s shared by %d users within %s",
+				evt.Fingerprint, users, s.opts.FingerprintWindow))
+		}
+	}
+
+	if evt.InteractionType == constant.View {
+		if err := s.repo.CountVideoView(ctx, evt.VideoID, evt.EventID); err != nil {
+			log.Printf("[detect] - [CountVideoView] - %v", err)
+			return "", err
+		}
+	} else if s.opts.RequireView {
+		views, err := s.repo.GetVideoViews(ctx, evt.VideoID)
+		if err != nil {
+			log.Printf("[detect] - [GetVideoViews] - %v", err)
+			return "", err
+		}
+		if views == 0 {
+			return fmt.Sprintf("%s on video %s without views", evt.InteractionType, evt.VideoID), nil
+		}
+	}
+	return "", nil
+}
+
+// flag flags a user and returns the reason
+func (s *Service) flag(ctx context.Context, userID string, reason string) (string, error) {
+	if err := s.repo.FlagUser(ctx, userID, reason); err != nil {
+		log.Printf("[detect] - [FlagUser] - %v", err)
+		return "", err
+	}
+	return reason, nil
+}
+
+// ListQuarantined retrieves quarantined events with the given status, or all if empty, newest first
+func (s *Service) ListQuarantined(
+	ctx context.Context, status entity.QuarantineStatus, limit int, offset int,
+) ([]*entity.QuarantinedEvent, error) {
+	events, err := s.repo.ListQuarantined(ctx, status, int64(limit), int64(offset))
+	if err != nil {
+		log.Printf("[ListQuarantined] - [ListQuarantined] - %v", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// ReleaseQuarantined marks a quarantined event as released and publishes it again, the score
+// consumer then applies it without screening. Releasing twice publishes again, which the consumer ignores.
+func (s *Service) ReleaseQuarantined(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	quarantined, err := s.repo.GetQuarantined(ctx, objectID)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[ReleaseQuarantined] - [GetQuarantined] - %v", err)
+		return err
+	}
+
+	if err := s.repo.MarkReleased(ctx, objectID); err != nil {
+		log.Printf("[ReleaseQuarantined] - [MarkReleased] - %v", err)
+		return err
+	}
+	if err := s.publisher.Publish(ctx, &quarantined.Event); err != nil {
+		log.Printf("[ReleaseQuarantined] - [Publish] - %v", err)
+		return err
+	}
+
+	log.Printf("[ReleaseQuarantined] - Released event %s", quarantined.Event.EventID)
+	return nil
+}
+
+// PurgeQuarantined removes a quarantined event without applying it
+func (s *Service) PurgeQuarantined(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	err = s.repo.DeleteQuarantined(ctx, objectID)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[PurgeQuarantined] - [DeleteQuarantined] - %v", err)
+		return err
+	}
+
+	log.Printf("[PurgeQuarantined] - Purged quarantined event %s", id)
+	return nil
+}
+
+// ListFlaggedUsers retrieves flagged users, most recently flagged first
+func (s *Service) ListFlaggedUsers(ctx context.Context, limit int, offset int) ([]*entity.FlaggedUser, error) {
+	users, err := s.repo.ListFlaggedUsers(ctx, int64(limit), int64(offset))
+	if err != nil {
+		log.Printf("[ListFlaggedUsers] - [ListFlaggedUsers] - %v", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// FlagUser flags a user manually, all of their later events are quarantined
+func (s *Service) FlagUser(ctx context.Context, userID string, reason string) error {
+	if reason == "" {
+		reason = "flagged by admin"
+	}
+	if err := s.repo.FlagUser(ctx, userID, reason); err != nil {
+		log.Printf("[FlagUser] - [FlagUser] - %v", err)
+		return err
+	}
+	return nil
+}
+
+// UnflagUser removes the flag of a user, events already in quarantine stay there
+func (s *Service) UnflagUser(ctx context.Context, userID string) error {
+	err := s.repo.UnflagUser(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return ErrUserNotFlagged
+	}
+	if err != nil {
+		log.Printf("[UnflagUser] - [UnflagUser] - %v", err)
+		return err
+	}
+	return nil
+}
+
+// SubtractUserContribution removes everything a flagged user added to the video scores and the
+// rankings, and returns the number of videos adjusted
+func (s *Service) SubtractUserContribution(ctx context.Context, userID string) (int64, error) {
+	flagged, err := s.repo.GetFlaggedUser(ctx, userID)
+	if err != nil {
+		log.Printf("[SubtractUserContribution] - [GetFlaggedUser] - %v", err)
+		return 0, err
+	}
+	if flagged == nil {
+		return 0, ErrUserNotFlagged
+	}
+
+	adjusted, err := s.contributions.SubtractUserContribution(ctx, userID, &s.opts.Ranking)
+	if err != nil {
+		log.Printf("[SubtractUserContribution] - [SubtractUserContribution] - %v", err)
+		return adjusted, err
+	}
+	return adjusted, nil
+}
@@ -0,0 +1,51 @@
+package fraud
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Action interface {
+	EnsureIndexes(ctx context.Context) error
+	CountUserEvent(ctx context.Context, userID string, eventID string, at time.Time, window time.Duration) (int64, error)
+	AddFingerprintUser(ctx context.Context, fingerprint string, userID string, at time.Time, window time.Duration) (int64, error)
+	CountVideoView(ctx context.Context, videoID string, eventID string) error
+	GetVideoViews(ctx context.Context, videoID string) (int64, error)
+	Quarantine(ctx context.Context, quarantined *entity.QuarantinedEvent) error
+	IsReleased(ctx context.Context, eventID string) (bool, error)
+	DeleteHeld(ctx context.Context, eventID string) (bool, error)
+	GetHeldEventIDs(ctx context.Context) ([]string, error)
+	ListQuarantined(ctx context.Context, status entity.QuarantineStatus, limit int64, offset int64) ([]*entity.QuarantinedEvent, error)
+	GetQuarantined(ctx context.Context, id primitive.ObjectID) (*entity.QuarantinedEvent, error)
+	MarkReleased(ctx context.Context, id primitive.ObjectID) error
+	DeleteQuarantined(ctx context.Context, id primitive.ObjectID) error
+	FlagUser(ctx context.Context, userID string, reason string) error
+	GetFlaggedUser(ctx context.Context, userID string) (*entity.FlaggedUser, error)
+	ListFlaggedUsers(ctx context.Context, limit int64, offset int64) ([]*entity.FlaggedUser, error)
+	UnflagUser(ctx context.Context, userID string) error
+}
+
+type Repository interface {
+	Action
+}
+
+// ContributionStore removes what a user added to the scores
+type ContributionStore interface {
+	SubtractUserContribution(ctx context.Context, userID string, policy *entity.RankingPolicy) (int64, error)
+}
+
+type UseCase interface {
+	EnsureIndexes(ctx context.Context) error
+	Screen(ctx context.Context, event *entity.InteractionEvent) (bool, error)
+	ListQuarantined(ctx context.Context, status entity.QuarantineStatus, limit int, offset int) ([]*entity.QuarantinedEvent, error)
+	ReleaseQuarantined(ctx context.Context, id string) error
+	PurgeQuarantined(ctx context.Context, id string) error
+	ListFlaggedUsers(ctx context.Context, limit int, offset int) ([]*entity.FlaggedUser, error)
+	FlagUser(ctx context.Context, userID string, reason string) error
+	UnflagUser(ctx context.Context, userID string) error
+	SubtractUserContribution(ctx context.Context, userID string) (int64, error)
+}
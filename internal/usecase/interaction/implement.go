@@ -2,84 +2,569 @@ package interaction
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"regexp"
+	"sort"
 	"time"
 
 	"go-server/internal/common/constant"
+	"go-server/internal/common/util"
 	"go-server/internal/entity"
 	userinteraction "go-server/internal/entity"
+	"go-server/internal/usecase/weight"
 
-	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// GoroutinePool manages a pool of goroutines to limit concurrency
-type GoroutinePool struct {
-	pool chan struct{}
-}
+var (
+	// ErrRequestInProgress is returned when a request with the same idempotency key is still being processed
+	ErrRequestInProgress = errors.New("a request with the same idempotency key is in progress")
+	// ErrUnknownInteractionType is returned when the interaction type is not in the weight table
+	ErrUnknownInteractionType = errors.New("unknown interaction type")
+	// ErrInvalidInteraction is returned when a required field of an interaction is missing
+	ErrInvalidInteraction = errors.New("invalid interaction")
+	// ErrInteractionNotFound is returned when retracting an interaction that does not exist or belongs to another user
+	ErrInteractionNotFound = errors.New("interaction not found")
+	// ErrInteractionRetracted is returned when retracting an interaction a second time
+	ErrInteractionRetracted = errors.New("interaction already retracted")
+	// ErrInteractionLimited is returned when an anti-spam rule rejects an interaction
+	ErrInteractionLimited = errors.New("interaction limit reached")
+	// ErrUnknownVideo is returned when catalog validation is on and the video is not in the catalog
+	ErrUnknownVideo = errors.New("unknown video")
+	// ErrVideoUnpublished is returned when only published videos are accepted and the video is not published
+	ErrVideoUnpublished = errors.New("video is not published")
+)
 
-// NewGoroutinePool creates a new GoroutinePool with the specified size
-func NewGoroutinePool(size int) *GoroutinePool {
-	return &GoroutinePool{
-		pool: make(chan struct{}, size),
-	}
-}
+// regionPattern matches the region codes interactions may carry, e.g. "vn" or "us-ca"
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z0-9]{1,8})?$`)
 
-// Add adds a goroutine to the pool
-func (p *GoroutinePool) Add() {
-	p.pool <- struct{}{}
+// Options configures the interaction service
+type Options struct {
+	// IdempotencyTTL is how long a completed idempotency key is remembered
+	IdempotencyTTL time.Duration
+	// IdempotencyLockTTL bounds how long a key stays reserved by a request that never finishes
+	IdempotencyLockTTL time.Duration
+	// Rules are the anti-spam rules by interaction type, types without a rule are not limited
+	Rules map[constant.InteractionType]*entity.InteractionRule
+	// VideoValidation decides whether interactions for unknown or unpublished videos are rejected
+	VideoValidation constant.VideoValidation
 }
 
-// Done removes a goroutine from the pool
-func (p *GoroutinePool) Done() {
-	<-p.pool
-}
-
-var goroutinePool = NewGoroutinePool(10)
-
 // Service handles interaction-related business logic
 type Service struct {
-	repo  Repository
-	redis *redis.Client
+	repo        Repository
+	idempotency IdempotencyStore
+	counters    CounterStore
+	audits      AuditStore
+	catalog     Catalog
+	weights     weight.UseCase
+	opts        Options
 }
 
 // NewService creates a new Service instance
-func NewService(r Repository, redis *redis.Client) *Service {
+func NewService(
+	r Repository, idempotency IdempotencyStore, counters CounterStore, audits AuditStore, catalog Catalog,
+	weights weight.UseCase, opts Options,
+) *Service {
 	return &Service{
-		repo:  r,
-		redis: redis,
+		repo:        r,
+		idempotency: idempotency,
+		counters:    counters,
+		audits:      audits,
+		catalog:     catalog,
+		weights:     weights,
+		opts:        opts,
 	}
 }
 
-// CreateNewInteraction processes a new user interaction and publishes it to Redis
+// EnsureIndexes prepares the interactions collection
+func (s *Service) EnsureIndexes(ctx context.Context) error {
+	return s.repo.EnsureIndexes(ctx)
+}
+
+// CreateNewInteraction stores a new user interaction together with its outbox message.
+// The outbox relay publishes the event afterwards, so an interaction is never stored without its event.
+// The anti-spam rules may reject the interaction with ErrInteractionLimited or discount its weight,
+// and the catalog validation may reject it with ErrUnknownVideo or ErrVideoUnpublished.
+// req.EventID is the client's idempotency key, scoped to the user and replaced with the event ID derived
+// from it. When it was already processed, nothing is stored and true is returned so the caller can
+// replay the original response.
 func (s *Service) CreateNewInteraction(
 	ctx context.Context, req *userinteraction.UserInteractionReq,
-) error {
-	// Insert interaction into the database
-	if err := s.repo.InsertOne(ctx, &entity.Interaction{
+) (bool, error) {
+	if _, ok := s.weights.Weight(req.InteractionType); !ok {
+		return false, ErrUnknownInteractionType
+	}
+	if err := normalizeRegion(req); err != nil {
+		return false, err
+	}
+	videos, err := s.lookupVideos(ctx, []*userinteraction.UserInteractionReq{req})
+	if err != nil {
+		log.Printf("[CreateNewInteraction] - [lookupVideos] - %v", err)
+		return false, err
+	}
+	if err := s.checkVideo(videos, req.VideoID); err != nil {
+		return false, err
+	}
+
+	keyed := req.EventID != ""
+	var key string
+	if !keyed {
+		req.EventID = primitive.NewObjectID().Hex()
+	} else {
+		key, req.EventID = scopeKey(req.UserID, req.EventID)
+		reserved, status, err := s.idempotency.Reserve(ctx, key, s.opts.IdempotencyLockTTL)
+		if err != nil {
+			log.Printf("[CreateNewInteraction] - [Reserve] - %v", err)
+			return false, err
+		}
+		if !reserved {
+			if status == constant.IdempotencyCompleted {
+				log.Printf("[CreateNewInteraction] - Replaying event %s for user: %s", req.EventID, req.UserID)
+				return true, nil
+			}
+			return false, ErrRequestInProgress
+		}
+	}
+
+	multiplier, err := s.applyRules(ctx, req)
+	if err != nil {
+		if !errors.Is(err, ErrInteractionLimited) {
+			log.Printf("[CreateNewInteraction] - [applyRules] - %v", err)
+		}
+		if keyed {
+			s.release(ctx, []string{key})
+		}
+		return false, err
+	}
+
+	replayed := false
+	if err := s.insert(ctx, req, multiplier); err != nil {
+		s.uncount(ctx, req)
+		// The event ID is derived from the key, so a stored event means the key expired after it was processed
+		if !keyed || !mongo.IsDuplicateKeyError(err) {
+			if keyed {
+				s.release(ctx, []string{key})
+			}
+			return false, err
+		}
+		replayed = true
+	}
+
+	if keyed {
+		if err := s.idempotency.Complete(ctx, key, s.opts.IdempotencyTTL); err != nil {
+			log.Printf("[CreateNewInteraction] - [Complete] - %v", err)
+		}
+	}
+	if replayed {
+		log.Printf("[CreateNewInteraction] - Replaying stored event %s for user: %s", req.EventID, req.UserID)
+		return true, nil
+	}
+
+	log.Printf("[CreateNewInteraction] - Interaction created successfully for user: %s", req.UserID)
+	return false, nil
+}
+
+// CreateInteractions stores a batch of interactions and their outbox messages in one transaction.
+// Every item is validated, checked against its idempotency key and the anti-spam rules on its own;
+// invalid or limited items are rejected and already processed ones replayed, without failing the rest of the batch.
+func (s *Service) CreateInteractions(
+	ctx context.Context, reqs []*userinteraction.UserInteractionReq,
+) (*entity.BulkInteractionResult, error) {
+	result := &entity.BulkInteractionResult{Items: make([]*entity.BulkItemResult, 0, len(reqs))}
+	now := time.Now()
+	var (
+		interactions []*entity.Interaction
+		messages     []*entity.OutboxMessage
+		accepted     []*entity.BulkItemResult
+		reserved     []string
+		counted      []*userinteraction.UserInteractionReq
+	)
+
+	videos, err := s.lookupVideos(ctx, reqs)
+	if err != nil {
+		log.Printf("[CreateInteractions] - [lookupVideos] - %v", err)
+		return nil, err
+	}
+	stored, err := s.lookupStored(ctx, reqs)
+	if err != nil {
+		log.Printf("[CreateInteractions] - [lookupStored] - %v", err)
+		return nil, err
+	}
+
+	for i, req := range reqs {
+		item := &entity.BulkItemResult{Index: i, EventID: req.EventID}
+		err := s.validate(req)
+		if err == nil {
+			err = s.checkVideo(videos, req.VideoID)
+		}
+		if err != nil {
+			item.Status, item.Error = entity.BulkItemRejected, err.Error()
+			result.Add(item)
+			continue
+		}
+
+		var key string
+		if req.EventID == "" {
+			req.EventID = primitive.NewObjectID().Hex()
+			item.EventID = req.EventID
+		} else {
+			key, req.EventID = scopeKey(req.UserID, req.EventID)
+			item.EventID = req.EventID
+			ok, status, err := s.idempotency.Reserve(ctx, key, s.opts.IdempotencyLockTTL)
+			if err != nil {
+				log.Printf("[CreateInteractions] - [Reserve] - %v", err)
+				s.uncountAll(ctx, counted)
+				s.release(ctx, reserved)
+				return nil, err
+			}
+			if !ok {
+				if status == constant.IdempotencyCompleted {
+					item.Status = entity.BulkItemReplayed
+				} else {
+					item.Status, item.Error = entity.BulkItemRejected, ErrRequestInProgress.Error()
+				}
+				result.Add(item)
+				continue
+			}
+			// A stored event means the key expired after it was processed, inserting it again would fail the batch
+			if stored[req.EventID] {
+				if err := s.idempotency.Complete(ctx, key, s.opts.IdempotencyTTL); err != nil {
+					log.Printf("[CreateInteractions] - [Complete] - %v", err)
+				}
+				item.Status = entity.BulkItemReplayed
+				result.Add(item)
+				continue
+			}
+		}
+
+		multiplier, err := s.applyRules(ctx, req)
+		if errors.Is(err, ErrInteractionLimited) {
+			// The item was never stored, so its key is given back and a later retry is not replayed
+			if key != "" {
+				s.release(ctx, []string{key})
+			}
+			item.Status, item.Error = entity.BulkItemRejected, err.Error()
+			result.Add(item)
+			continue
+		}
+		if key != "" {
+			reserved = append(reserved, key)
+		}
+		if err != nil {
+			log.Printf("[CreateInteractions] - [applyRules] - %v", err)
+			s.uncountAll(ctx, counted)
+			s.release(ctx, reserved)
+			return nil, err
+		}
+		counted = append(counted, req)
+
+		interaction, message := newInteraction(req, multiplier, now)
+		interactions = append(interactions, interaction)
+		messages = append(messages, message)
+		item.Status = entity.BulkItemAccepted
+		accepted = append(accepted, item)
+	}
+
+	if len(interactions) > 0 {
+		if err := s.repo.InsertManyWithOutbox(ctx, interactions, messages); err != nil {
+			log.Printf("[CreateInteractions] - [InsertManyWithOutbox] - %v", err)
+			s.uncountAll(ctx, counted)
+			s.release(ctx, reserved)
+			return nil, err
+		}
+	}
+	for _, key := range reserved {
+		if err := s.idempotency.Complete(ctx, key, s.opts.IdempotencyTTL); err != nil {
+			log.Printf("[CreateInteractions] - [Complete] - %v", err)
+		}
+	}
+
+	for _, item := range accepted {
+		result.Add(item)
+	}
+	sort.Slice(result.Items, func(i, j int) bool { return result.Items[i].Index < result.Items[j].Index })
+
+	log.Printf("[CreateInteractions] - Accepted %d, replayed %d, rejected %d interactions",
+		result.Accepted, result.Replayed, result.Rejected)
+	return result, nil
+}
+
+// RetractInteraction undoes a user's interaction, e.g. an unlike. The interaction is kept but marked as
+// retracted, and a retraction event is published through the outbox so the score consumer subtracts
+// what the interaction added. eventID is either the event ID or the idempotency key the user sent it with.
+func (s *Service) RetractInteraction(ctx context.Context, eventID string, userID string) error {
+	interaction, err := s.repo.GetByEventID(ctx, eventID)
+	if err == mongo.ErrNoDocuments {
+		_, scoped := scopeKey(userID, eventID)
+		interaction, err = s.repo.GetByEventID(ctx, scoped)
+	}
+	if err == mongo.ErrNoDocuments || (err == nil && interaction.UserID != userID) {
+		return ErrInteractionNotFound
+	}
+	if err != nil {
+		log.Printf("[RetractInteraction] - [GetByEventID] - %v", err)
+		return err
+	}
+	if interaction.RetractedAt != nil {
+		return ErrInteractionRetracted
+	}
+	eventID = interaction.EventID
+
+	now := time.Now()
+	message := &entity.OutboxMessage{
+		Event: entity.InteractionEvent{
+			EventID:         constant.RetractionEventPrefix + eventID,
+			UserID:          interaction.UserID,
+			VideoID:         interaction.VideoID,
+			InteractionType: interaction.InteractionType,
+			OccurredAt:      interaction.CreatedAt,
+			RetractsEventID: eventID,
+			Multiplier:      interaction.Multiplier,
+			Fingerprint:     interaction.Fingerprint,
+			Region:          interaction.Region,
+		},
+		Status:        entity.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := s.repo.RetractWithOutbox(ctx, eventID, now, message); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrInteractionRetracted
+		}
+		log.Printf("[RetractInteraction] - [RetractWithOutbox] - %v", err)
+		return err
+	}
+
+	// Undoing an interaction frees its slot, so a user can like again after unliking
+	if err := s.counters.Decrement(ctx, counterKey(interaction.InteractionType, interaction.UserID, interaction.VideoID)); err != nil {
+		log.Printf("[RetractInteraction] - [Decrement] - %v", err)
+	}
+
+	log.Printf("[RetractInteraction] - Interaction %s retracted for user: %s", eventID, userID)
+	return nil
+}
+
+// applyRules counts the interaction against the rule of its type. It returns ErrInteractionLimited
+// when the rule rejects it, without counting it, or the multiplier of its weight when the rule discounts it, nil otherwise.
+// Rejections and discounts are audited.
+func (s *Service) applyRules(ctx context.Context, req *userinteraction.UserInteractionReq) (*float64, error) {
+	rule, ok := s.opts.Rules[req.InteractionType]
+	if !ok {
+		return nil, nil
+	}
+	window := time.Duration(rule.WindowSeconds) * time.Second
+	count, err := s.counters.Increment(ctx, counterKey(req.InteractionType, req.UserID, req.VideoID), window)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &entity.InteractionAudit{
+		EventID:         req.EventID,
 		UserID:          req.UserID,
 		VideoID:         req.VideoID,
 		InteractionType: req.InteractionType,
+		Count:           count,
 		CreatedAt:       time.Now(),
-	}); err != nil {
-		log.Printf("[CreateNewInteraction] - [InsertOne] - %v", err)
+	}
+	switch {
+	case rule.Limit > 0 && count > rule.Limit:
+		// A rejected attempt takes no slot, otherwise an unlike would not free one for the next like
+		s.uncount(ctx, req)
+		audit.Action = entity.AuditRejected
+		audit.Reason = fmt.Sprintf("at most %d %s per user and video%s", rule.Limit, req.InteractionType, windowText(window))
+		s.audit(ctx, audit)
+		return nil, fmt.Errorf("%w: %s", ErrInteractionLimited, audit.Reason)
+	case rule.DecayAfter > 0 && count > rule.DecayAfter:
+		audit.Action = entity.AuditDiscounted
+		audit.Multiplier = math.Pow(rule.DecayFactor, float64(count-rule.DecayAfter))
+		audit.Reason = fmt.Sprintf("%s number %d by the same user%s, after %d the weight decays by %g",
+			req.InteractionType, count, windowText(window), rule.DecayAfter, rule.DecayFactor)
+		s.audit(ctx, audit)
+		return util.ToPtr(audit.Multiplier), nil
+	}
+	return nil, nil
+}
+
+// audit records a rule decision, a failure is only logged so it never blocks ingestion
+func (s *Service) audit(ctx context.Context, audit *entity.InteractionAudit) {
+	if err := s.audits.Insert(ctx, audit); err != nil {
+		log.Printf("[applyRules] - [Insert] - %v", err)
+	}
+}
+
+// uncount gives back the rule counter taken by an interaction that was not stored
+func (s *Service) uncount(ctx context.Context, req *userinteraction.UserInteractionReq) {
+	if _, ok := s.opts.Rules[req.InteractionType]; !ok {
+		return
+	}
+	if err := s.counters.Decrement(ctx, counterKey(req.InteractionType, req.UserID, req.VideoID)); err != nil {
+		log.Printf("[uncount] - [Decrement] - %v", err)
+	}
+}
+
+// uncountAll gives back the rule counters of a batch that was not stored
+func (s *Service) uncountAll(ctx context.Context, reqs []*userinteraction.UserInteractionReq) {
+	for _, req := range reqs {
+		s.uncount(ctx, req)
+	}
+}
+
+// counterKey identifies the rule counter of a user's interactions of one type on one video
+func counterKey(interactionType constant.InteractionType, userID string, videoID string) string {
+	return fmt.Sprintf("%s:%s:%s", interactionType, userID, videoID)
+}
+
+// windowText describes a rule window for audit reasons
+func windowText(window time.Duration) string {
+	if window <= 0 {
+		return ""
+	}
+	return " per " + window.String()
+}
+
+// validate checks the fields a stored interaction needs
+func (s *Service) validate(req *userinteraction.UserInteractionReq) error {
+	switch {
+	case req.UserID == "":
+		return fmt.Errorf("%w: user_id is required", ErrInvalidInteraction)
+	case req.VideoID == "":
+		return fmt.Errorf("%w: video_id is required", ErrInvalidInteraction)
+	}
+	if err := normalizeRegion(req); err != nil {
 		return err
 	}
+	if _, ok := s.weights.Weight(req.InteractionType); !ok {
+		return ErrUnknownInteractionType
+	}
+	return nil
+}
 
-	// Publish the interaction event asynchronously using GoroutinePool
-	goroutinePool.Add()
-	go func() {
-		defer goroutinePool.Done()
-		interactionEvent := entity.InteractionEvent{
-			UserID:          req.UserID,
-			VideoID:         req.VideoID,
-			InteractionType: req.InteractionType,
-			PublishedAt:     time.Now(),
+// normalizeRegion lowercases the region of an interaction and rejects one that is not a region code,
+// so clients cannot create arbitrary segment rankings
+func normalizeRegion(req *userinteraction.UserInteractionReq) error {
+	req.Region = util.NormalizeLabel(req.Region)
+	if req.Region != "" && !regionPattern.MatchString(req.Region) {
+		return fmt.Errorf("%w: invalid region %q", ErrInvalidInteraction, req.Region)
+	}
+	return nil
+}
+
+// lookupVideos fetches the catalog entries of the videos of reqs, or nothing when catalog validation is off
+func (s *Service) lookupVideos(
+	ctx context.Context, reqs []*userinteraction.UserInteractionReq,
+) (map[string]*entity.Video, error) {
+	if s.opts.VideoValidation != constant.VideoValidationKnown && s.opts.VideoValidation != constant.VideoValidationPublished {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(reqs))
+	videoIDs := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if req.VideoID != "" && !seen[req.VideoID] {
+			seen[req.VideoID] = true
+			videoIDs = append(videoIDs, req.VideoID)
 		}
-		if err := s.redis.Publish(ctx, constant.InteractionEventsChannel, interactionEvent); err != nil {
-			log.Printf("[CreateNewInteraction] - [Publish] - %v", err)
+	}
+	return s.catalog.GetVideos(ctx, videoIDs)
+}
+
+// lookupStored returns which of the event IDs derived from the idempotency keys of reqs are already stored
+func (s *Service) lookupStored(
+	ctx context.Context, reqs []*userinteraction.UserInteractionReq,
+) (map[string]bool, error) {
+	eventIDs := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if req.EventID != "" {
+			_, eventID := scopeKey(req.UserID, req.EventID)
+			eventIDs = append(eventIDs, eventID)
 		}
-	}()
+	}
+	return s.repo.GetStoredEventIDs(ctx, eventIDs)
+}
 
-	log.Printf("[CreateNewInteraction] - Interaction created successfully for user: %s", req.UserID)
+// checkVideo rejects an interaction whose video fails the catalog validation, videos are the
+// catalog entries fetched by lookupVideos
+func (s *Service) checkVideo(videos map[string]*entity.Video, videoID string) error {
+	switch s.opts.VideoValidation {
+	case constant.VideoValidationKnown, constant.VideoValidationPublished:
+	default:
+		return nil
+	}
+
+	video, ok := videos[videoID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownVideo, videoID)
+	}
+	if s.opts.VideoValidation == constant.VideoValidationPublished && video.Status != entity.VideoPublished {
+		return fmt.Errorf("%w: %s", ErrVideoUnpublished, videoID)
+	}
+	return nil
+}
+
+// scopeKey scopes a client's idempotency key to its user, so two users sending the same key never collide,
+// and derives the event ID from the scoped key so every retry of a request carries the same event ID
+func scopeKey(userID string, key string) (string, string) {
+	scoped := userID + ":" + key
+	sum := sha256.Sum256([]byte(scoped))
+	return scoped, hex.EncodeToString(sum[:16])
+}
+
+// release frees idempotency keys reserved by a request that failed
+func (s *Service) release(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.idempotency.Release(ctx, key); err != nil {
+			log.Printf("[release] - [Release] - %v", err)
+		}
+	}
+}
+
+// insert stores the interaction and its outbox message
+func (s *Service) insert(ctx context.Context, req *userinteraction.UserInteractionReq, multiplier *float64) error {
+	interaction, message := newInteraction(req, multiplier, time.Now())
+	if err := s.repo.InsertWithOutbox(ctx, interaction, message); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Printf("[CreateNewInteraction] - [InsertWithOutbox] - %v", err)
+		}
+		return err
+	}
 	return nil
 }
+
+// newInteraction builds the interaction document and the outbox message carrying its event
+func newInteraction(
+	req *userinteraction.UserInteractionReq, multiplier *float64, now time.Time,
+) (*entity.Interaction, *entity.OutboxMessage) {
+	interaction := &entity.Interaction{
+		EventID:         req.EventID,
+		UserID:          req.UserID,
+		VideoID:         req.VideoID,
+		InteractionType: req.InteractionType,
+		CreatedAt:       now,
+		Multiplier:      multiplier,
+		Fingerprint:     req.Fingerprint,
+		Region:          req.Region,
+	}
+	message := &entity.OutboxMessage{
+		Event: entity.InteractionEvent{
+			EventID:         req.EventID,
+			UserID:          req.UserID,
+			VideoID:         req.VideoID,
+			InteractionType: req.InteractionType,
+			OccurredAt:      now,
+			Multiplier:      multiplier,
+			Fingerprint:     req.Fingerprint,
+			Region:          req.Region,
+		},
+		Status:        entity.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	return interaction, message
+}
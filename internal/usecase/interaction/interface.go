@@ -2,19 +2,52 @@ package interaction
 
 import (
 	"context"
+	"time"
 
+	"go-server/internal/common/constant"
 	"go-server/internal/entity"
 	userinteraction "go-server/internal/entity"
 )
 
 type Action interface {
-	InsertOne(ctx context.Context, interactionData *entity.Interaction) error
+	EnsureIndexes(ctx context.Context) error
+	InsertWithOutbox(ctx context.Context, interactionData *entity.Interaction, message *entity.OutboxMessage) error
+	InsertManyWithOutbox(ctx context.Context, interactions []*entity.Interaction, messages []*entity.OutboxMessage) error
+	GetByEventID(ctx context.Context, eventID string) (*entity.Interaction, error)
+	GetStoredEventIDs(ctx context.Context, eventIDs []string) (map[string]bool, error)
+	RetractWithOutbox(ctx context.Context, eventID string, retractedAt time.Time, message *entity.OutboxMessage) error
 }
 
 type Repository interface {
 	Action
 }
 
+// IdempotencyStore remembers client supplied idempotency keys
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, constant.IdempotencyStatus, error)
+	Complete(ctx context.Context, key string, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// CounterStore counts interactions per key within fixed windows
+type CounterStore interface {
+	Increment(ctx context.Context, key string, window time.Duration) (int64, error)
+	Decrement(ctx context.Context, key string) error
+}
+
+// AuditStore records interactions that were rejected or discounted by a rule
+type AuditStore interface {
+	Insert(ctx context.Context, audit *entity.InteractionAudit) error
+}
+
+// Catalog looks up videos in the video catalog
+type Catalog interface {
+	GetVideos(ctx context.Context, videoIDs []string) (map[string]*entity.Video, error)
+}
+
 type UseCase interface {
-	CreateNewInteraction(ctx context.Context, req *userinteraction.UserInteractionReq) error
+	EnsureIndexes(ctx context.Context) error
+	CreateNewInteraction(ctx context.Context, req *userinteraction.UserInteractionReq) (bool, error)
+	CreateInteractions(ctx context.Context, reqs []*userinteraction.UserInteractionReq) (*entity.BulkInteractionResult, error)
+	RetractInteraction(ctx context.Context, eventID string, userID string) error
 }
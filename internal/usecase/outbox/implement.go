@@ -0,0 +1,113 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Options configures the outbox relay
+type Options struct {
+	BatchSize     int
+	PollInterval  time.Duration
+	Lease         time.Duration
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	SentRetention time.Duration
+}
+
+// RelayService publishes pending outbox messages to the event bus
+type RelayService struct {
+	repo      Repository
+	publisher event.EventPublisher
+	opts      Options
+}
+
+// NewRelayService creates a new RelayService instance
+func NewRelayService(r Repository, publisher event.EventPublisher, opts Options) *RelayService {
+	return &RelayService{
+		repo:      r,
+		publisher: publisher,
+		opts:      opts,
+	}
+}
+
+// StartRelay polls the outbox every PollInterval until ctx is done, a zero PollInterval disables the relay
+func (s *RelayService) StartRelay(ctx context.Context) {
+	if err := s.repo.EnsureIndexes(ctx, s.opts.SentRetention); err != nil {
+		log.Printf("[StartRelay] - [EnsureIndexes] - %v", err)
+	}
+	if s.opts.PollInterval <= 0 {
+		log.Println("Outbox relay is disabled, stored interactions are not published")
+		return
+	}
+
+	log.Println("Started outbox relay")
+
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.relayBatch(ctx)
+		}
+	}
+}
+
+// relayBatch claims up to BatchSize due messages and publishes them together
+func (s *RelayService) relayBatch(ctx context.Context) {
+	messages, err := s.repo.ClaimBatch(ctx, primitive.NewObjectID().Hex(), s.opts.BatchSize, s.opts.Lease)
+	if err != nil {
+		log.Printf("[relayBatch] - [ClaimBatch] - %v", err)
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	events := make([]*entity.InteractionEvent, 0, len(messages))
+	now := time.Now()
+	for _, message := range messages {
+		message.Event.PublishedAt = now
+		events = append(events, &message.Event)
+	}
+
+	if err := s.publisher.PublishBatch(ctx, events); err != nil {
+		for _, message := range messages {
+			nextAttemptAt := time.Now().Add(s.backoff(message.Attempts))
+			log.Printf("[relayBatch] - [PublishBatch] - message %s attempt %d, retrying at %s: %v",
+				message.ID.Hex(), message.Attempts, nextAttemptAt.Format(time.RFC3339), err)
+			if err := s.repo.MarkFailed(ctx, message.ID, nextAttemptAt, err.Error()); err != nil {
+				log.Printf("[relayBatch] - [MarkFailed] - %v", err)
+			}
+		}
+		return
+	}
+
+	for _, message := range messages {
+		if err := s.repo.MarkSent(ctx, message.ID); err != nil {
+			// The lease expires and the message is published again, consumers must tolerate duplicates
+			log.Printf("[relayBatch] - [MarkSent] - %v", err)
+		}
+	}
+}
+
+// backoff doubles BaseBackoff for every failed attempt, capped at MaxBackoff
+func (s *RelayService) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseBackoff
+	for i := 1; i < attempts && delay < s.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.opts.MaxBackoff {
+		delay = s.opts.MaxBackoff
+	}
+	return delay
+}
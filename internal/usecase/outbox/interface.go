@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Action interface {
+	EnsureIndexes(ctx context.Context, sentRetention time.Duration) error
+	ClaimBatch(ctx context.Context, token string, limit int, lease time.Duration) ([]*entity.OutboxMessage, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, reason string) error
+}
+
+type Repository interface {
+	Action
+}
+
+type UseCase interface {
+	StartRelay(ctx context.Context)
+}
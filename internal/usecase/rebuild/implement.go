@@ -0,0 +1,175 @@
+package rebuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/common/util"
+	"go-server/internal/entity"
+)
+
+// ErrRebuildRunning is returned when a rebuild is requested while one is in progress on any instance
+var ErrRebuildRunning = errors.New("a ranking rebuild is already running")
+
+// errLeaseLost stops a rebuild when another instance took over the lease
+var errLeaseLost = errors.New("rebuild lease lost")
+
+// Options configures the ranking rebuild
+type Options struct {
+	// BatchSize is the number of score documents read and written to Redis per round trip
+	BatchSize int
+	// OnStartup selects whether RebuildOnStartup rebuilds never, always or only when the global ranking is missing
+	OnStartup constant.RebuildMode
+	// Lease is how long the rebuild lease is held without renewal, it is renewed after every batch
+	Lease time.Duration
+	// PersonalMaxSize and PersonalTTL bound the rebuilt personal rankings like the live ones
+	PersonalMaxSize int64
+	PersonalTTL     time.Duration
+}
+
+// Service rebuilds the Redis rankings from the MongoDB score collections and tracks the progress
+// of the last rebuild. Replicas coordinate through a Redis lease, so at most one rebuild runs at a time.
+type Service struct {
+	repo     Repository
+	instance string
+	opts     Options
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository, opts Options) *Service {
+	hostname, _ := os.Hostname()
+	return &Service{
+		repo:     r,
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		opts:     opts,
+	}
+}
+
+// RebuildOnStartup rebuilds the rankings according to the OnStartup mode
+func (s *Service) RebuildOnStartup(ctx context.Context) {
+	switch s.opts.OnStartup {
+	case constant.RebuildAlways:
+	case constant.RebuildIfEmpty:
+		empty, err := s.repo.IsRankingEmpty(ctx)
+		if err != nil {
+			log.Printf("[RebuildOnStartup] - [IsRankingEmpty] - %v", err)
+			return
+		}
+		if !empty {
+			return
+		}
+	default:
+		return
+	}
+
+	log.Println("Rebuilding rankings from MongoDB on startup")
+	if _, err := s.Rebuild(ctx); err != nil {
+		log.Printf("[RebuildOnStartup] - [Rebuild] - %v", err)
+	}
+}
+
+// Rebuild rebuilds the global and personal rankings and returns the final status
+func (s *Service) Rebuild(ctx context.Context) (*entity.RebuildStatus, error) {
+	status, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.run(ctx, status)
+	return status, err
+}
+
+// StartRebuild starts a rebuild in the background and returns its initial status.
+// The rebuild runs on its own context, as ctx usually belongs to the request that started it.
+func (s *Service) StartRebuild(ctx context.Context) (*entity.RebuildStatus, error) {
+	status, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	initial := *status
+	go func() {
+		if err := s.run(context.Background(), status); err != nil {
+			log.Printf("[StartRebuild] - [run] - %v", err)
+		}
+	}()
+	return &initial, nil
+}
+
+// GetStatus returns the status of the running or last rebuild of any instance, or nil if none ran
+func (s *Service) GetStatus(ctx context.Context) (*entity.RebuildStatus, error) {
+	status, err := s.repo.GetRebuildStatus(ctx)
+	if err != nil {
+		log.Printf("[GetStatus] - [GetRebuildStatus] - %v", err)
+		return nil, err
+	}
+	return status, nil
+}
+
+// begin takes the rebuild lease and records a running status
+func (s *Service) begin(ctx context.Context) (*entity.RebuildStatus, error) {
+	acquired, err := s.repo.AcquireRebuildLease(ctx, s.instance, s.opts.Lease)
+	if err != nil {
+		log.Printf("[Rebuild] - [AcquireRebuildLease] - %v", err)
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrRebuildRunning
+	}
+
+	status := &entity.RebuildStatus{State: entity.RebuildRunning, Instance: s.instance, StartedAt: time.Now()}
+	if err := s.repo.SaveRebuildStatus(ctx, status); err != nil {
+		log.Printf("[Rebuild] - [SaveRebuildStatus] - %v", err)
+		if err := s.repo.ReleaseRebuildLease(ctx, s.instance); err != nil {
+			log.Printf("[Rebuild] - [ReleaseRebuildLease] - %v", err)
+		}
+		return nil, err
+	}
+	return status, nil
+}
+
+// run rebuilds the global ranking, then the personal rankings. After every batch it renews the lease,
+// stopping if it was lost, and saves the progress. The lease is released once the rebuild ends.
+func (s *Service) run(ctx context.Context, status *entity.RebuildStatus) error {
+	_, err := s.repo.RebuildGlobalRanking(ctx, s.opts.BatchSize, func(videos int64) error {
+		status.Videos = videos
+		log.Printf("[Rebuild] - Ranked %d videos", videos)
+		return s.progress(ctx, status)
+	})
+	if err == nil {
+		_, _, err = s.repo.RebuildPersonalRankings(ctx, s.opts.BatchSize, s.opts.PersonalMaxSize, s.opts.PersonalTTL, func(users int64, entries int64) error {
+			status.Users, status.PersonalEntries = users, entries
+			log.Printf("[Rebuild] - Ranked %d personal entries of %d users", entries, users)
+			return s.progress(ctx, status)
+		})
+	}
+
+	status.FinishedAt = util.ToPtr(time.Now())
+	status.State = entity.RebuildCompleted
+	if err != nil {
+		status.State = entity.RebuildFailed
+		status.Error = err.Error()
+	}
+	if err := s.repo.SaveRebuildStatus(ctx, status); err != nil {
+		log.Printf("[Rebuild] - [SaveRebuildStatus] - %v", err)
+	}
+	if err := s.repo.ReleaseRebuildLease(ctx, s.instance); err != nil {
+		log.Printf("[Rebuild] - [ReleaseRebuildLease] - %v", err)
+	}
+	return err
+}
+
+// progress renews the lease for another Lease, failing if another instance holds it, and saves the status
+func (s *Service) progress(ctx context.Context, status *entity.RebuildStatus) error {
+	renewed, err := s.repo.RenewRebuildLease(ctx, s.instance, s.opts.Lease)
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return errLeaseLost
+	}
+	return s.repo.SaveRebuildStatus(ctx, status)
+}
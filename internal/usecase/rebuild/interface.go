@@ -0,0 +1,32 @@
+package rebuild
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/entity"
+)
+
+type Action interface {
+	AcquireRebuildLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	RenewRebuildLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	ReleaseRebuildLease(ctx context.Context, owner string) error
+	SaveRebuildStatus(ctx context.Context, status *entity.RebuildStatus) error
+	GetRebuildStatus(ctx context.Context) (*entity.RebuildStatus, error)
+	IsRankingEmpty(ctx context.Context) (bool, error)
+	RebuildGlobalRanking(ctx context.Context, batchSize int, progress func(videos int64) error) (int64, error)
+	RebuildPersonalRankings(
+		ctx context.Context, batchSize int, maxSize int64, ttl time.Duration, progress func(users int64, entries int64) error,
+	) (int64, int64, error)
+}
+
+type Repository interface {
+	Action
+}
+
+type UseCase interface {
+	RebuildOnStartup(ctx context.Context)
+	Rebuild(ctx context.Context) (*entity.RebuildStatus, error)
+	StartRebuild(ctx context.Context) (*entity.RebuildStatus, error)
+	GetStatus(ctx context.Context) (*entity.RebuildStatus, error)
+}
@@ -0,0 +1,181 @@
+package recompute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/score"
+	"go-server/internal/usecase/weight"
+)
+
+// ErrNothingToSwap is returned when swapping before a recompute produced fresh score collections
+var ErrNothingToSwap = errors.New("no recomputed scores to swap, run a recompute first")
+
+// ErrInvalidRange is returned when the recompute time range ends before it starts
+var ErrInvalidRange = errors.New("recompute range must end after it starts")
+
+// ErrPartialRecompute is returned when swapping scores recomputed from only part of the interaction log,
+// they would replace the scores of every interaction outside the range with nothing
+var ErrPartialRecompute = errors.New("only a recompute of the full interaction log can be swapped in, run it without -from and -to")
+
+// Options configures the score recompute
+type Options struct {
+	// BatchSize is the number of interactions replayed and written per round trip
+	BatchSize int
+	// ReportTop is the number of largest score differences listed in the report
+	ReportTop int
+}
+
+// Service recomputes the scores from the interactions log into fresh collections and swaps them in
+type Service struct {
+	repo         Repository
+	interactions InteractionLog
+	fraud        FraudStore
+	rankings     RankingRebuilder
+	opts         Options
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository, interactions InteractionLog, fraud FraudStore, rankings RankingRebuilder, opts Options) *Service {
+	return &Service{
+		repo:         r,
+		interactions: interactions,
+		fraud:        fraud,
+		rankings:     rankings,
+		opts:         opts,
+	}
+}
+
+// Recompute replays the interactions selected by req with the given weights, using the same scoring
+// as the score consumer, into the recomputed score collections and reports how they differ from the
+// current scores. Retracted interactions and events held in quarantine are skipped, they add nothing
+// to the live scores either. The current scores are left untouched until Swap.
+func (s *Service) Recompute(ctx context.Context, weights weight.UseCase, req *entity.RecomputeReq) (*entity.RecomputeReport, error) {
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, ErrInvalidRange
+	}
+	report := &entity.RecomputeReport{
+		Request:   *req,
+		Weights:   weights.GetWeightTable(),
+		StartedAt: time.Now(),
+	}
+
+	held, err := s.heldEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[string]bool{}
+	if req.ExcludeFlagged {
+		users, err := s.fraud.ListFlaggedUsers(ctx, 0, 0)
+		if err != nil {
+			log.Printf("[Recompute] - [ListFlaggedUsers] - %v", err)
+			return nil, err
+		}
+		for _, user := range users {
+			excluded[user.UserID] = true
+		}
+	}
+
+	if err := s.repo.PrepareRecompute(ctx); err != nil {
+		log.Printf("[Recompute] - [PrepareRecompute] - %v", err)
+		return nil, err
+	}
+
+	if err := s.interactions.ScanInteractions(ctx, req.From, req.To, s.opts.BatchSize, func(batch []*entity.Interaction) error {
+		videos := map[string]float64{}
+		personal := map[string]map[string]float64{}
+		events := map[string]float64{}
+		for _, interaction := range batch {
+			if interaction.RetractedAt != nil || held[interaction.EventID] || excluded[interaction.UserID] {
+				report.Skipped++
+				continue
+			}
+			// Unknown types score 0 like they do in the consumer, but still get a score document
+			delta, _ := score.InteractionDelta(weights, interaction.InteractionType, interaction.Multiplier)
+			if interaction.EventID != "" {
+				events[interaction.EventID] = delta
+			}
+			videos[interaction.VideoID] += delta
+			if personal[interaction.UserID] == nil {
+				personal[interaction.UserID] = map[string]float64{}
+			}
+			personal[interaction.UserID][interaction.VideoID] += delta
+			report.Replayed++
+		}
+		if err := s.repo.AddRecomputedScores(ctx, videos, personal, events); err != nil {
+			return err
+		}
+		log.Printf("[Recompute] - Replayed %d interactions, skipped %d", report.Replayed, report.Skipped)
+		return nil
+	}); err != nil {
+		log.Printf("[Recompute] - [ScanInteractions] - %v", err)
+		return nil, err
+	}
+
+	report.Videos, report.Personal, err = s.repo.DiffRecomputedScores(ctx, int64(s.opts.ReportTop))
+	if err != nil {
+		log.Printf("[Recompute] - [DiffRecomputedScores] - %v", err)
+		return nil, err
+	}
+	report.FinishedAt = time.Now()
+
+	if err := s.repo.SaveRecomputeRun(ctx, &entity.RecomputeRun{
+		Request:    *req,
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+	}); err != nil {
+		log.Printf("[Recompute] - [SaveRecomputeRun] - %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// Swap replaces the current scores with the recomputed ones, keeping the current ones as a backup,
+// rewrites the deltas of the ledger entries older than the recompute to the recomputed ones, then
+// rebuilds the Redis rankings from the new scores. Only a finished recompute of the full interaction log can be swapped in.
+func (s *Service) Swap(ctx context.Context) error {
+	run, err := s.repo.GetRecomputeRun(ctx)
+	if err != nil {
+		log.Printf("[Swap] - [GetRecomputeRun] - %v", err)
+		return err
+	}
+	exists, err := s.repo.HasRecomputedScores(ctx)
+	if err != nil {
+		log.Printf("[Swap] - [HasRecomputedScores] - %v", err)
+		return err
+	}
+	if run == nil || !exists {
+		return ErrNothingToSwap
+	}
+	if !run.Request.Full() {
+		return ErrPartialRecompute
+	}
+
+	if err := s.repo.SwapRecomputedScores(ctx, run.StartedAt); err != nil {
+		log.Printf("[Swap] - [SwapRecomputedScores] - %v", err)
+		return err
+	}
+	if _, err := s.rankings.Rebuild(ctx); err != nil {
+		log.Printf("[Swap] - [Rebuild] - %v", err)
+		return fmt.Errorf("scores were swapped but the rankings were not rebuilt: %w", err)
+	}
+	return nil
+}
+
+// heldEvents returns the IDs of the events held in quarantine
+func (s *Service) heldEvents(ctx context.Context) (map[string]bool, error) {
+	eventIDs, err := s.fraud.GetHeldEventIDs(ctx)
+	if err != nil {
+		log.Printf("[Recompute] - [GetHeldEventIDs] - %v", err)
+		return nil, err
+	}
+	held := make(map[string]bool, len(eventIDs))
+	for _, eventID := range eventIDs {
+		held[eventID] = true
+	}
+	return held, nil
+}
@@ -0,0 +1,46 @@
+package recompute
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/entity"
+	"go-server/internal/usecase/weight"
+)
+
+type Action interface {
+	PrepareRecompute(ctx context.Context) error
+	AddRecomputedScores(
+		ctx context.Context, videos map[string]float64, personal map[string]map[string]float64, events map[string]float64,
+	) error
+	DiffRecomputedScores(ctx context.Context, top int64) (*entity.ScoreDiffSummary, *entity.ScoreDiffSummary, error)
+	SaveRecomputeRun(ctx context.Context, run *entity.RecomputeRun) error
+	GetRecomputeRun(ctx context.Context) (*entity.RecomputeRun, error)
+	HasRecomputedScores(ctx context.Context) (bool, error)
+	SwapRecomputedScores(ctx context.Context, scannedFrom time.Time) error
+}
+
+type Repository interface {
+	Action
+}
+
+// InteractionLog reads the raw interactions the scores are recomputed from
+type InteractionLog interface {
+	ScanInteractions(ctx context.Context, from *time.Time, to *time.Time, batchSize int, fn func([]*entity.Interaction) error) error
+}
+
+// FraudStore tells which interactions fraud detection kept out of the scores
+type FraudStore interface {
+	GetHeldEventIDs(ctx context.Context) ([]string, error)
+	ListFlaggedUsers(ctx context.Context, limit int64, offset int64) ([]*entity.FlaggedUser, error)
+}
+
+// RankingRebuilder reloads the Redis rankings from the score collections
+type RankingRebuilder interface {
+	Rebuild(ctx context.Context) (*entity.RebuildStatus, error)
+}
+
+type UseCase interface {
+	Recompute(ctx context.Context, weights weight.UseCase, req *entity.RecomputeReq) (*entity.RecomputeReport, error)
+	Swap(ctx context.Context) error
+}
@@ -0,0 +1,290 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"go-server/internal/common/util"
+	"go-server/internal/entity"
+)
+
+// errLeaseLost stops a pass when another instance took over the lease
+var errLeaseLost = errors.New("reconcile lease lost")
+
+// Options configures the reconciler
+type Options struct {
+	// Interval is how often an instance tries to take the lease and run a pass, 0 disables the reconciler
+	Interval time.Duration
+	// BatchSize is the number of videos compared per round trip
+	BatchSize int
+	// ConfirmDelay is how long a drifting video is left before it is checked again and repaired,
+	// so events still being applied are not mistaken for drift
+	ConfirmDelay time.Duration
+	// Tolerance is the largest difference between two scores still considered equal
+	Tolerance float64
+	// PendingWindow is how long the event bus may still redeliver an event after it was applied to the
+	// scores. A drifting video with an event applied within it but missing from Redis is not repaired,
+	// as the redelivery finishes the ranking update and a repair would count the event twice.
+	PendingWindow time.Duration
+}
+
+// Service periodically compares the video scores in MongoDB with the global ranking in Redis
+// and repairs the ranking where they drifted apart
+type Service struct {
+	repo     Repository
+	instance string
+	opts     Options
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository, opts Options) *Service {
+	hostname, _ := os.Hostname()
+	return &Service{
+		repo:     r,
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		opts:     opts,
+	}
+}
+
+// StartReconciler runs a pass every Interval on the instance holding the lease until ctx is done.
+// The lease is kept until it expires, so across all replicas at most one pass starts per Interval.
+func (s *Service) StartReconciler(ctx context.Context) {
+	if s.opts.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			acquired, err := s.repo.AcquireLease(ctx, s.instance, s.opts.Interval)
+			if err != nil {
+				log.Printf("[StartReconciler] - [AcquireLease] - %v", err)
+				continue
+			}
+			if !acquired {
+				continue
+			}
+
+			run := s.reconcile(ctx)
+			if err := s.repo.SaveRun(ctx, run); err != nil {
+				log.Printf("[StartReconciler] - [SaveRun] - %v", err)
+			}
+			log.Printf("Reconciled %d videos: %d mismatched, %d missing, %d stale, %d repaired",
+				run.Checked, run.Mismatched, run.Missing, run.Stale, run.Repaired)
+		}
+	}
+}
+
+// GetStats returns the drift counters of all passes and the last pass
+func (s *Service) GetStats(ctx context.Context) (*entity.ReconcileStats, error) {
+	stats, err := s.repo.GetStats(ctx)
+	if err != nil {
+		log.Printf("[GetStats] - [GetStats] - %v", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// reconcile runs one pass: every scored video is checked against the ranking, then every ranked
+// video against the scores. A failed pass keeps the counters it reached and records the error.
+func (s *Service) reconcile(ctx context.Context) *entity.ReconcileRun {
+	run := &entity.ReconcileRun{Instance: s.instance, StartedAt: time.Now()}
+	err := s.reconcileScores(ctx, run)
+	if err == nil {
+		err = s.reconcileRanking(ctx, run)
+	}
+	if err != nil {
+		log.Printf("[reconcile] - %v", err)
+		run.Error = err.Error()
+	}
+	run.FinishedAt = time.Now()
+	return run
+}
+
+// reconcileScores walks the video scores in batches and repairs videos ranked with another score or not ranked
+func (s *Service) reconcileScores(ctx context.Context, run *entity.ReconcileRun) error {
+	after := ""
+	for {
+		scores, last, err := s.repo.ListScores(ctx, after, int64(s.opts.BatchSize))
+		if err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		run.Checked += int64(len(scores))
+
+		cached, err := s.repo.GetCachedScores(ctx, keys(scores))
+		if err != nil {
+			return err
+		}
+		if err := s.repair(ctx, run, scores, cached); err != nil {
+			return err
+		}
+		if err := s.renewLease(ctx); err != nil {
+			return err
+		}
+		after = last
+	}
+}
+
+// reconcileRanking scans the global ranking and removes videos that have no score document
+func (s *Service) reconcileRanking(ctx context.Context, run *entity.ReconcileRun) error {
+	var cursor uint64
+	for {
+		cached, next, err := s.repo.ScanRanking(ctx, cursor, int64(s.opts.BatchSize))
+		if err != nil {
+			return err
+		}
+		if len(cached) > 0 {
+			scores, err := s.repo.GetScores(ctx, keys(cached))
+			if err != nil {
+				return err
+			}
+			// Videos ranked with another score were already checked while walking the scores
+			orphans := map[string]float64{}
+			for videoID, score := range cached {
+				if _, ok := scores[videoID]; !ok {
+					orphans[videoID] = score
+				}
+			}
+			if err := s.repair(ctx, run, map[string]float64{}, orphans); err != nil {
+				return err
+			}
+		}
+		if err := s.renewLease(ctx); err != nil {
+			return err
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// drifting returns the IDs of the videos whose score and cached score differ, either one possibly missing
+func (s *Service) drifting(scores map[string]float64, cached map[string]float64) []string {
+	var videoIDs []string
+	for videoID := range union(scores, cached) {
+		score, scored := scores[videoID]
+		cachedScore, ranked := cached[videoID]
+		if scored != ranked || math.Abs(score-cachedScore) > s.opts.Tolerance {
+			videoIDs = append(videoIDs, videoID)
+		}
+	}
+	return videoIDs
+}
+
+// repair waits ConfirmDelay, reads the drifting videos again and repairs the ones still showing the
+// same scores as when the drift was found. A video whose score moved meanwhile was being updated
+// and is left to the next pass.
+func (s *Service) repair(
+	ctx context.Context, run *entity.ReconcileRun, scores map[string]float64, cached map[string]float64,
+) error {
+	videoIDs := s.drifting(scores, cached)
+	if len(videoIDs) == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(s.opts.ConfirmDelay):
+	}
+
+	confirmedScores, err := s.repo.GetScores(ctx, videoIDs)
+	if err != nil {
+		return err
+	}
+	confirmedCached, err := s.repo.GetCachedScores(ctx, videoIDs)
+	if err != nil {
+		return err
+	}
+	pending, err := s.repo.GetPendingVideos(ctx, videoIDs, time.Now().Add(-s.opts.PendingWindow))
+	if err != nil {
+		return err
+	}
+
+	for _, videoID := range videoIDs {
+		score, scored := scores[videoID]
+		cachedScore, ranked := cached[videoID]
+		if !unchanged(confirmedScores, videoID, score, scored) || !unchanged(confirmedCached, videoID, cachedScore, ranked) {
+			continue
+		}
+		if pending[videoID] {
+			run.Pending++
+			continue
+		}
+
+		var expected, repaired *float64
+		switch {
+		case !ranked:
+			run.Missing++
+			run.Drift(math.Abs(score))
+			repaired = util.ToPtr(score)
+		case !scored:
+			run.Stale++
+			run.Drift(math.Abs(cachedScore))
+			expected = util.ToPtr(cachedScore)
+		default:
+			run.Mismatched++
+			run.Drift(math.Abs(score - cachedScore))
+			expected, repaired = util.ToPtr(cachedScore), util.ToPtr(score)
+		}
+		changed, err := s.repo.RepairCachedScore(ctx, videoID, expected, repaired)
+		if err != nil {
+			return err
+		}
+		if changed {
+			run.Repaired++
+		}
+	}
+	return nil
+}
+
+// renewLease extends the lease for another Interval, failing if another instance holds it
+func (s *Service) renewLease(ctx context.Context) error {
+	renewed, err := s.repo.RenewLease(ctx, s.instance, s.opts.Interval)
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// unchanged reports whether a video still has the given score, or is still missing if it was
+func unchanged(scores map[string]float64, videoID string, score float64, present bool) bool {
+	again, ok := scores[videoID]
+	return ok == present && again == score
+}
+
+// keys returns the video IDs of a score map
+func keys(scores map[string]float64) []string {
+	videoIDs := make([]string, 0, len(scores))
+	for videoID := range scores {
+		videoIDs = append(videoIDs, videoID)
+	}
+	return videoIDs
+}
+
+// union returns the video IDs present in either map
+func union(a map[string]float64, b map[string]float64) map[string]struct{} {
+	videoIDs := make(map[string]struct{}, len(a)+len(b))
+	for videoID := range a {
+		videoIDs[videoID] = struct{}{}
+	}
+	for videoID := range b {
+		videoIDs[videoID] = struct{}{}
+	}
+	return videoIDs
+}
@@ -0,0 +1,30 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/entity"
+)
+
+type Action interface {
+	AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	RenewLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	ListScores(ctx context.Context, afterVideoID string, limit int64) (map[string]float64, string, error)
+	GetScores(ctx context.Context, videoIDs []string) (map[string]float64, error)
+	GetCachedScores(ctx context.Context, videoIDs []string) (map[string]float64, error)
+	ScanRanking(ctx context.Context, cursor uint64, count int64) (map[string]float64, uint64, error)
+	GetPendingVideos(ctx context.Context, videoIDs []string, since time.Time) (map[string]bool, error)
+	RepairCachedScore(ctx context.Context, videoID string, expected *float64, score *float64) (bool, error)
+	SaveRun(ctx context.Context, run *entity.ReconcileRun) error
+	GetStats(ctx context.Context) (*entity.ReconcileStats, error)
+}
+
+type Repository interface {
+	Action
+}
+
+type UseCase interface {
+	StartReconciler(ctx context.Context)
+	GetStats(ctx context.Context) (*entity.ReconcileStats, error)
+}
@@ -0,0 +1,266 @@
+package related
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"go-server/internal/entity"
+)
+
+// Options configures the related videos job and recommendations
+type Options struct {
+	// Interval is how often an instance tries to take the lease and run the job, 0 disables the schedule
+	Interval time.Duration
+	// TTL is how long computed related videos are kept, it should outlast Interval
+	TTL time.Duration
+	// BatchSize is the number of documents read and videos written per round trip
+	BatchSize int
+	// TopK is the number of related videos kept per video
+	TopK int
+	// MinSupport is the number of users that must have engaged with both videos for them to be related
+	MinSupport int
+	// MaxVideosPerUser caps the videos of a user considered, their top scored ones, bounding the pairs per user
+	MaxVideosPerUser int
+	// MaxPairsPerVideo caps the co-engaged videos counted per video, bounding the memory of the job
+	MaxPairsPerVideo int
+	// RecentVideos is the number of a user's recent videos whose related videos are recommended
+	RecentVideos int
+}
+
+// Service computes item-to-item related videos from co-engagement and recommends videos from them
+type Service struct {
+	repo        Repository
+	engagements EngagementStore
+	instance    string
+	opts        Options
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository, engagements EngagementStore, opts Options) *Service {
+	hostname, _ := os.Hostname()
+	return &Service{
+		repo:        r,
+		engagements: engagements,
+		instance:    fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		opts:        opts,
+	}
+}
+
+// StartScheduler runs the job once at startup, then every Interval, on the instance holding the lease
+// until ctx is done. The lease is kept until it expires, so across all replicas at most one run starts
+// per Interval, and a restart does not run it again before then.
+func (s *Service) StartScheduler(ctx context.Context) {
+	if err := s.repo.EnsureIndexes(ctx); err != nil {
+		log.Printf("[StartScheduler] - [EnsureIndexes] - %v", err)
+	}
+	if s.opts.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runScheduled(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runScheduled takes the lease and runs the job if no other instance holds it
+func (s *Service) runScheduled(ctx context.Context) {
+	acquired, err := s.repo.AcquireLease(ctx, s.instance, s.opts.Interval)
+	if err != nil {
+		log.Printf("[StartScheduler] - [AcquireLease] - %v", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	if _, err := s.ComputeRelated(ctx); err != nil {
+		log.Printf("[StartScheduler] - [ComputeRelated] - %v", err)
+	}
+}
+
+// ComputeRelated computes the co-engagement similarity of every pair of videos engaged with by at least
+// MinSupport common users, as the cosine similarity common users / sqrt(users of a * users of b),
+// and stores the TopK most similar videos of each video. At most MaxPairsPerVideo co-engaged videos are
+// counted per video: when a video's count is full, the videos seen by fewer than MinSupport users so far
+// are dropped to make room, and if none are, the new video is not counted.
+func (s *Service) ComputeRelated(ctx context.Context) (*entity.RelatedReport, error) {
+	report := &entity.RelatedReport{StartedAt: time.Now()}
+	users := map[string]int{}
+	pairs := newPairCounter(s.opts.MaxPairsPerVideo, s.opts.MinSupport)
+	if err := s.repo.ScanEngagements(ctx, s.opts.BatchSize, s.opts.MaxVideosPerUser, func(_ string, videoIDs []string) error {
+		report.Users++
+		for i, a := range videoIDs {
+			users[a]++
+			for _, b := range videoIDs[i+1:] {
+				pairs.count(a, b)
+				pairs.count(b, a)
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Printf("[ComputeRelated] - [ScanEngagements] - %v", err)
+		return nil, err
+	}
+
+	report.Pruned = pairs.pruned
+
+	related := make(map[string][]*entity.RelatedVideo, len(pairs.common))
+	for a, neighbors := range pairs.common {
+		var videos []*entity.RelatedVideo
+		for b, both := range neighbors {
+			if both < s.opts.MinSupport {
+				continue
+			}
+			videos = append(videos, &entity.RelatedVideo{
+				VideoID: b,
+				Score:   float64(both) / math.Sqrt(float64(users[a])*float64(users[b])),
+			})
+		}
+		if len(videos) == 0 {
+			continue
+		}
+		related[a] = top(videos, s.opts.TopK)
+		report.Videos++
+		report.Pairs += int64(len(related[a]))
+	}
+
+	if err := s.repo.SaveRelated(ctx, related, s.opts.BatchSize, s.opts.TTL); err != nil {
+		log.Printf("[ComputeRelated] - [SaveRelated] - %v", err)
+		return nil, err
+	}
+	report.FinishedAt = time.Now()
+	log.Printf("Computed related videos of %d videos from %d users", report.Videos, report.Users)
+	return report, nil
+}
+
+// ListRelated retrieves the videos most often engaged with by the users who engaged with a video
+func (s *Service) ListRelated(ctx context.Context, videoID string, limit int) ([]*entity.RelatedVideo, error) {
+	videos, err := s.repo.GetRelated(ctx, videoID, int64(limit))
+	if err != nil {
+		log.Printf("[ListRelated] - [GetRelated] - %v", err)
+		return nil, err
+	}
+	return videos, nil
+}
+
+// ListRecommended ranks the related videos of a user's RecentVideos recent videos by their summed
+// similarity, the more recent a video the more its related videos weigh. Videos the user already
+// engaged with are left out.
+func (s *Service) ListRecommended(ctx context.Context, userID string, limit int) ([]*entity.RelatedVideo, error) {
+	recent, err := s.repo.GetRecentVideos(ctx, userID, int64(s.opts.RecentVideos))
+	if err != nil {
+		log.Printf("[ListRecommended] - [GetRecentVideos] - %v", err)
+		return nil, err
+	}
+	if len(recent) == 0 {
+		return []*entity.RelatedVideo{}, nil
+	}
+	related, err := s.repo.GetRelatedOf(ctx, recent, int64(s.opts.TopK))
+	if err != nil {
+		log.Printf("[ListRecommended] - [GetRelatedOf] - %v", err)
+		return nil, err
+	}
+
+	scores := map[string]float64{}
+	for i, videoID := range recent {
+		for _, neighbor := range related[videoID] {
+			scores[neighbor.VideoID] += neighbor.Score / float64(i+1)
+		}
+	}
+	if len(scores) == 0 {
+		return []*entity.RelatedVideo{}, nil
+	}
+
+	candidates := make([]string, 0, len(scores))
+	for videoID := range scores {
+		candidates = append(candidates, videoID)
+	}
+	engaged, err := s.engagements.GetPersonalScores(ctx, userID, candidates)
+	if err != nil {
+		log.Printf("[ListRecommended] - [GetPersonalScores] - %v", err)
+		return nil, err
+	}
+
+	videos := make([]*entity.RelatedVideo, 0, len(scores))
+	for videoID, score := range scores {
+		if _, ok := engaged[videoID]; !ok {
+			videos = append(videos, &entity.RelatedVideo{VideoID: videoID, Score: score})
+		}
+	}
+	return top(videos, limit), nil
+}
+
+// pairCounter counts the users who engaged with both videos of a pair, holding at most maxPairs
+// co-engaged videos per video
+type pairCounter struct {
+	common     map[string]map[string]int
+	maxPairs   int
+	minSupport int
+	// saturated holds the videos whose co-engaged videos all reached minSupport, counts only grow
+	// so nothing can be pruned from them any more
+	saturated map[string]bool
+	pruned    int64
+}
+
+func newPairCounter(maxPairs int, minSupport int) *pairCounter {
+	return &pairCounter{
+		common:     map[string]map[string]int{},
+		maxPairs:   maxPairs,
+		minSupport: minSupport,
+		saturated:  map[string]bool{},
+	}
+}
+
+// count increments the number of users who engaged with both a and b. When a already holds maxPairs
+// co-engaged videos, the ones below minSupport are pruned to make room, or b is dropped if there are none.
+func (c *pairCounter) count(a string, b string) {
+	if c.common[a] == nil {
+		c.common[a] = map[string]int{}
+	}
+	neighbors := c.common[a]
+	if _, ok := neighbors[b]; ok || c.maxPairs <= 0 || len(neighbors) < c.maxPairs {
+		neighbors[b]++
+		return
+	}
+
+	if !c.saturated[a] {
+		for neighbor, both := range neighbors {
+			if both < c.minSupport {
+				delete(neighbors, neighbor)
+				c.pruned++
+			}
+		}
+		c.saturated[a] = len(neighbors) >= c.maxPairs
+	}
+	if c.saturated[a] {
+		c.pruned++
+		return
+	}
+	neighbors[b]++
+}
+
+// top sorts videos by descending score, then video ID, and keeps the first k
+func top(videos []*entity.RelatedVideo, k int) []*entity.RelatedVideo {
+	sort.Slice(videos, func(i, j int) bool {
+		if videos[i].Score != videos[j].Score {
+			return videos[i].Score > videos[j].Score
+		}
+		return videos[i].VideoID < videos[j].VideoID
+	})
+	if len(videos) > k {
+		videos = videos[:k]
+	}
+	return videos
+}
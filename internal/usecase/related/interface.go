@@ -0,0 +1,34 @@
+package related
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/entity"
+)
+
+type Action interface {
+	EnsureIndexes(ctx context.Context) error
+	AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	ScanEngagements(ctx context.Context, batchSize int, maxVideos int, fn func(userID string, videoIDs []string) error) error
+	SaveRelated(ctx context.Context, related map[string][]*entity.RelatedVideo, batchSize int, ttl time.Duration) error
+	GetRelated(ctx context.Context, videoID string, limit int64) ([]*entity.RelatedVideo, error)
+	GetRelatedOf(ctx context.Context, videoIDs []string, limit int64) (map[string][]*entity.RelatedVideo, error)
+	GetRecentVideos(ctx context.Context, userID string, limit int64) ([]string, error)
+}
+
+type Repository interface {
+	Action
+}
+
+// EngagementStore tells which videos a user engaged with
+type EngagementStore interface {
+	GetPersonalScores(ctx context.Context, userID string, videoIDs []string) (map[string]float64, error)
+}
+
+type UseCase interface {
+	StartScheduler(ctx context.Context)
+	ComputeRelated(ctx context.Context) (*entity.RelatedReport, error)
+	ListRelated(ctx context.Context, videoID string, limit int) ([]*entity.RelatedVideo, error)
+	ListRecommended(ctx context.Context, userID string, limit int) ([]*entity.RelatedVideo, error)
+}
@@ -2,147 +2,714 @@ package score
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"go-server/internal/common/constant"
+	"go-server/internal/common/util"
 	"go-server/internal/entity"
+	"go-server/internal/usecase/event"
+	"go-server/internal/usecase/weight"
 
-	"github.com/go-redis/redis/v8"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInvalidCursor is returned when a ranking cursor was not produced by this service
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrVideoNotRanked is returned when a video has no score in the global ranking
+var ErrVideoNotRanked = errors.New("video is not ranked")
+
+// ErrSegmentDisabled is returned when a ranking is filtered by a segment dimension events are not fanned out to
+var ErrSegmentDisabled = errors.New("segment dimension is disabled")
+
 // ScoreService handles score-related business logic
 // It interacts with Redis for caching and MongoDB for persistence
 type ScoreService struct {
-	redisClient *redis.Client
-	repo        Repository
+	subscriber event.EventSubscriber
+	repo       Repository
+	weights    weight.UseCase
+	screener   Screener
+	catalog    Catalog
+	opts       Options
+}
+
+// Options configures the score service
+type Options struct {
+	// ProcessedEventTTL is how long an applied event ID is remembered for deduplication
+	ProcessedEventTTL time.Duration
+	// HotHalfLife is the time after which an event's contribution to the hot ranking halves
+	HotHalfLife time.Duration
+	// HotRebaseInterval is how often the hot epoch age is checked
+	HotRebaseInterval time.Duration
+	// HotRebaseAfter is the epoch age after which hot scores are rebased to stay within float range
+	HotRebaseAfter time.Duration
+	// HotMinScore drops videos whose decayed hot score fell below it when rebasing
+	HotMinScore float64
+	// HourBucketTTL and DayBucketTTL are how long hourly and daily leaderboard buckets are kept
+	HourBucketTTL time.Duration
+	DayBucketTTL  time.Duration
+	// WindowCacheTTL is how long an aggregated 24h or 7d leaderboard is reused
+	WindowCacheTTL time.Duration
+	// TrendingBaselineHours is how many hours before the current one form the trending baseline
+	TrendingBaselineHours int
+	// TrendingSmoothing dampens the velocity of videos with little activity
+	TrendingSmoothing float64
+	// PersonalMaxSize is the number of top videos kept in a personal ranking, 0 keeps all
+	PersonalMaxSize int64
+	// PersonalTTL is how long a personal ranking is kept without activity before it is evicted
+	// and lazily rehydrated from MongoDB, 0 keeps it forever
+	PersonalTTL time.Duration
+	// BlendPersonalWeight, BlendGlobalWeight and BlendTrendingWeight weigh the personal, global and
+	// trending scores in the blended ranking, each normalized to its top candidate
+	BlendPersonalWeight float64
+	BlendGlobalWeight   float64
+	BlendTrendingWeight float64
+	// BlendCandidates is the number of top videos taken from each ranking into the blended ranking
+	BlendCandidates int
+	// SegmentDimensions are the dimensions each event is fanned out to segment rankings by, none disables them
+	SegmentDimensions []constant.SegmentDimension
+	// SegmentCacheTTL is how long an intersection of segment rankings is reused
+	SegmentCacheTTL time.Duration
 }
 
 // NewScoreService creates a new instance of ScoreService
-func NewScoreService(r Repository, redisClient *redis.Client) *ScoreService {
+func NewScoreService(
+	r Repository, subscriber event.EventSubscriber, weights weight.UseCase, screener Screener, catalog Catalog, opts Options,
+) *ScoreService {
 	return &ScoreService{
-		redisClient: redisClient,
-		repo:        r,
+		subscriber: subscriber,
+		repo:       r,
+		weights:    weights,
+		screener:   screener,
+		catalog:    catalog,
+		opts:       opts,
 	}
 }
 
-// StartEventConsumer listens to Redis Pub/Sub for interaction events and processes them
+// StartEventConsumer consumes interaction events from the subscriber and processes them
 func (s *ScoreService) StartEventConsumer(ctx context.Context) {
-	sub := s.redisClient.Subscribe(ctx, constant.InteractionEventsChannel)
-	ch := sub.Channel()
+	if err := s.repo.EnsureIndexes(ctx, s.opts.ProcessedEventTTL); err != nil {
+		log.Printf("Failed to ensure score indexes: %v", err)
+	}
+	if err := s.screener.EnsureIndexes(ctx); err != nil {
+		log.Printf("Failed to ensure quarantine indexes: %v", err)
+	}
+	go s.startHotRebaser(ctx)
+	if err := s.subscriber.Subscribe(ctx, s.ApplyEvent); err != nil {
+		log.Printf("Interaction event consumer stopped: %v", err)
+	}
+}
 
-	log.Println("Started Redis consumer for interaction events")
+// ApplyEvent applies a single interaction event to the global and personalized scores exactly once.
+// The MongoDB scores and the Redis rankings are each guarded by a processed marker for the event ID,
+// so a redelivered event, or one that failed halfway, only applies the missing part.
+// New events are screened for fraud first, suspicious ones are quarantined instead of applied.
+// A retraction event applies the negated delta of the event it retracts, to the segment rankings it went to.
+// A returned error leaves the event unacknowledged so it is delivered again.
+func (s *ScoreService) ApplyEvent(ctx context.Context, event *entity.InteractionEvent) error {
+	if event.EventID == "" {
+		event.EventID = primitive.NewObjectID().Hex()
+		log.Printf("Event for user %s on video %s has no ID, it cannot be deduplicated", event.UserID, event.VideoID)
+	}
+	// A redelivered event skips screening and scoring and only finishes the rankings
+	change, err := s.repo.GetProcessedEvent(ctx, event.EventID)
+	if err != nil {
+		log.Printf("Failed to get processed event %s: %v", event.EventID, err)
+		return err
+	}
+	if change != nil && change.SubtractedAt != nil {
+		log.Printf("Event %s was taken out with its flagged user's contribution or retracted before it was applied, skipping", event.EventID)
+		return nil
+	}
+	if change != nil {
+		log.Printf("Event %s was already applied to the scores", event.EventID)
+	} else {
+		held, err := s.screener.Screen(ctx, event)
+		if err != nil {
+			log.Printf("Failed to screen event %s: %v", event.EventID, err)
+			return err
+		}
+		if held {
+			log.Printf("Event %s of user %s is held back by fraud screening", event.EventID, event.UserID)
+			return nil
+		}
+		if change, err = s.applyScore(ctx, event); err != nil {
+			return err
+		}
+	}
+	// The personal ranking takes the stored score, so trimmed or rehydrated entries stay exact
+	personalScore, err := s.repo.GetPersonalScore(ctx, event.UserID, event.VideoID)
+	if err != nil {
+		log.Printf("Failed to get personal score of event %s: %v", event.EventID, err)
+		return err
+	}
 
-	for msg := range ch {
-		go func(payload string) {
-			var event entity.InteractionEvent
-			if err := json.Unmarshal([]byte(payload), &event); err != nil {
-				log.Printf("Failed to unmarshal event: %v", err)
-				return
-			}
+	if _, err := s.repo.ApplyCachedScore(ctx, change, personalScore, &entity.RankingPolicy{
+		MarkerTTL:             s.opts.ProcessedEventTTL,
+		HotHalfLife:           s.opts.HotHalfLife,
+		HourBucketTTL:         s.opts.HourBucketTTL,
+		DayBucketTTL:          s.opts.DayBucketTTL,
+		TrendingBaselineHours: s.opts.TrendingBaselineHours,
+		TrendingSmoothing:     s.opts.TrendingSmoothing,
+		PersonalMaxSize:       s.opts.PersonalMaxSize,
+		PersonalTTL:           s.opts.PersonalTTL,
+	}); err != nil {
+		log.Printf("Failed to apply cached score of event %s: %v", event.EventID, err)
+		return err
+	}
+
+	log.Printf("Successfully processed event %s for user %s on video %s", event.EventID, event.UserID, event.VideoID)
+	return nil
+}
 
-			log.Printf("Processing event: %v", event)
-			if err := s.UpdateVideoScoreInDB(ctx, &event); err != nil {
-				log.Printf("Failed to update video score in DB: %v", err)
+// applyScore computes the delta and segment rankings of a screened event and applies it to the MongoDB
+// scores. It returns the ledger entry the Redis rankings must be brought up to.
+func (s *ScoreService) applyScore(ctx context.Context, event *entity.InteractionEvent) (*entity.ProcessedEvent, error) {
+	delta, ok := InteractionDelta(s.weights, event.InteractionType, event.Multiplier)
+	if !ok {
+		// The type was accepted at ingestion but has since been removed from the weight table
+		log.Printf("Event %s has unknown interaction type %q, it scores 0", event.EventID, event.InteractionType)
+	}
+	occurredAt := event.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = event.PublishedAt
+	}
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	var segments []string
+	var err error
+	if event.RetractsEventID != "" {
+		// Subtract what the original event added, even if the weight table or the video metadata changed since
+		original, err := s.repo.GetProcessedEvent(ctx, event.RetractsEventID)
+		if err != nil {
+			log.Printf("Failed to get retracted event %s: %v", event.RetractsEventID, err)
+			return nil, err
+		}
+		switch {
+		case original != nil && original.SubtractedAt != nil:
+			// The original was already taken out with its flagged user's contribution
+			delta, segments = 0, nil
+		case original != nil:
+			delta, segments = original.Delta, original.Segments
+		case time.Since(occurredAt) < s.opts.ProcessedEventTTL:
+			// The original would still be in the ledger, so it was not applied yet, e.g. it is in outbox
+			// backoff or quarantine. A tombstone makes it apply nothing when it arrives.
+			if err := s.tombstone(ctx, event, occurredAt); err != nil {
+				return nil, err
 			}
-			if err := s.UpdatePersonalizedScore(ctx, &event); err != nil {
-				log.Printf("Failed to update personalized score: %v", err)
+			delta, segments = 0, nil
+		default:
+			// The original's ledger entry may have expired, its delta is no longer known
+			if segments, err = s.segmentsOf(ctx, event); err != nil {
+				return nil, err
 			}
-			log.Printf("Successfully processed event for user %s on video %s", event.UserID, event.VideoID)
-		}(msg.Payload)
+		}
+		delta = -delta
+	} else if segments, err = s.segmentsOf(ctx, event); err != nil {
+		return nil, err
 	}
-}
 
-// UpdatePersonalizedScore updates the personalized score for a user and video
-// It updates both the database and the Redis cache
-func (s *ScoreService) UpdatePersonalizedScore(ctx context.Context, event *entity.InteractionEvent) error {
-	videoID := event.VideoID
-	userID := event.UserID
-	newScore := event.InteractionType.GetScore()
+	change := &entity.ProcessedEvent{
+		EventID:     event.EventID,
+		UserID:      event.UserID,
+		VideoID:     event.VideoID,
+		Delta:       delta,
+		OccurredAt:  occurredAt,
+		ProcessedAt: time.Now(),
+		Segments:    segments,
+	}
+	applied, err := s.repo.ApplyScore(ctx, change)
+	if err != nil {
+		log.Printf("Failed to apply score of event %s: %v", event.EventID, err)
+		return nil, err
+	}
+	if !applied {
+		log.Printf("Event %s was applied to the scores concurrently", event.EventID)
+		// Finish the rankings with what the other attempt recorded, the metadata may have changed since
+		processed, err := s.repo.GetProcessedEvent(ctx, event.EventID)
+		if err != nil {
+			log.Printf("Failed to get processed event %s: %v", event.EventID, err)
+			return nil, err
+		}
+		if processed != nil {
+			change = processed
+		}
+	}
+	return change, nil
+}
 
-	currentScore, err := s.repo.GetPersonalScore(ctx, userID, videoID)
-	if err != nil && err != mongo.ErrNoDocuments {
-		log.Printf("Failed to get current personalized score for user %s on video %s: %v", userID, videoID, err)
+// tombstone records that the original of a retraction must apply nothing. It returns an error, so the
+// retraction is delivered again and finds the original's delta, if the original was applied meanwhile.
+func (s *ScoreService) tombstone(ctx context.Context, retraction *entity.InteractionEvent, occurredAt time.Time) error {
+	now := time.Now()
+	placed, err := s.repo.InsertTombstone(ctx, &entity.ProcessedEvent{
+		EventID:      retraction.RetractsEventID,
+		UserID:       retraction.UserID,
+		VideoID:      retraction.VideoID,
+		OccurredAt:   occurredAt,
+		ProcessedAt:  now,
+		SubtractedAt: &now,
+	})
+	if err != nil {
+		log.Printf("Failed to record tombstone of retracted event %s: %v", retraction.RetractsEventID, err)
 		return err
 	}
+	if !placed {
+		return fmt.Errorf("retracted event %s was applied concurrently", retraction.RetractsEventID)
+	}
+	log.Printf("Event %s is retracted before it was applied, it will apply nothing", retraction.RetractsEventID)
+	return nil
+}
 
-	if err == mongo.ErrNoDocuments {
-		log.Printf("No current personalized score found for user %s on video %s, inserting new score", userID, videoID)
-		if err := s.repo.InsertPersonalScore(ctx, userID, videoID, newScore); err != nil {
-			log.Printf("Failed to insert new personalized score for user %s on video %s: %v", userID, videoID, err)
-			return err
+// segmentsOf derives the segment rankings of an event from the video's catalog metadata and the event's region
+func (s *ScoreService) segmentsOf(ctx context.Context, event *entity.InteractionEvent) ([]string, error) {
+	if len(s.opts.SegmentDimensions) == 0 {
+		return nil, nil
+	}
+	videos, err := s.catalog.GetVideos(ctx, []string{event.VideoID})
+	if err != nil {
+		log.Printf("Failed to get metadata of video %s for event %s: %v", event.VideoID, event.EventID, err)
+		return nil, err
+	}
+	video := videos[event.VideoID]
+	if video == nil {
+		video = &entity.Video{}
+	}
+
+	var segments []string
+	for _, dimension := range s.opts.SegmentDimensions {
+		var value string
+		switch dimension {
+		case constant.SegmentCategory:
+			value = video.Category
+		case constant.SegmentCreator:
+			value = video.CreatorID
+		case constant.SegmentLanguage:
+			value = video.Language
+		case constant.SegmentRegion:
+			value = event.Region
 		}
-	} else {
-		log.Printf("Updating personalized score for user %s on video %s", userID, videoID)
-		if err := s.repo.IncrementPersonalScore(ctx, userID, videoID, newScore); err != nil {
-			log.Printf("Failed to update personalized score for user %s on video %s: %v", userID, videoID, err)
-			return err
+		if value != "" {
+			segments = append(segments, entity.Segment(dimension, value))
 		}
-		newScore += currentScore
 	}
+	return segments, nil
+}
+
+// InteractionDelta is the score an interaction adds: the weight of its type, scaled by the anti-spam
+// multiplier if a rule discounted it. It reports false, scoring 0, if the type is not in the weight table.
+func InteractionDelta(weights weight.UseCase, interactionType constant.InteractionType, multiplier *float64) (float64, bool) {
+	delta, ok := weights.Weight(interactionType)
+	if multiplier != nil {
+		delta *= *multiplier
+	}
+	return delta, ok
+}
+
+// ListTopRankedVideos retrieves a page of top-ranked videos with their scores
+func (s *ScoreService) ListTopRankedVideos(ctx context.Context, cursor string, limit int) (*entity.RankingPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	videos, err := s.repo.GetTopRankedVideos(ctx, after, int64(limit))
+	if err != nil {
+		log.Printf("Failed to get top ranked videos: %v", err)
+		return nil, err
+	}
+	return newRankingPage(videos, limit), nil
+}
 
-	log.Printf("Updating personalized ranking cache for user %s on video %s", userID, videoID)
-	go func() {
-		if err := s.repo.UpdatePersonalizedRankingCache(ctx, userID, videoID, newScore); err != nil {
-			log.Printf("Failed to update personalized ranking cache for user %s on video %s: %v", userID, videoID, err)
+// ListHotRankedVideos retrieves a page of videos ordered by their time-decayed score.
+// Stored hot scores are relative to the hot epoch, they are decayed to the current time here.
+func (s *ScoreService) ListHotRankedVideos(ctx context.Context, cursor string, limit int) (*entity.RankingPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	videos, err := s.repo.GetTopHotVideos(ctx, after, int64(limit))
+	if err != nil {
+		log.Printf("Failed to get hot ranked videos: %v", err)
+		return nil, err
+	}
+	epoch, err := s.repo.GetHotEpoch(ctx)
+	if err != nil {
+		log.Printf("Failed to get hot ranking epoch: %v", err)
+		return nil, err
+	}
+
+	page := newRankingPage(videos, limit)
+	factor := math.Exp2(-time.Since(epoch).Seconds() / s.opts.HotHalfLife.Seconds())
+	for _, video := range page.Items {
+		video.Score *= factor
+	}
+	return page, nil
+}
+
+// ListWindowRankedVideos retrieves a page of top-ranked videos within a time window
+func (s *ScoreService) ListWindowRankedVideos(
+	ctx context.Context, window constant.RankingWindow, cursor string, limit int,
+) (*entity.RankingPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	videos, err := s.repo.GetTopWindowVideos(ctx, window, after, int64(limit), s.opts.WindowCacheTTL)
+	if err != nil {
+		log.Printf("Failed to get top ranked videos in window %s: %v", window, err)
+		return nil, err
+	}
+	return newRankingPage(videos, limit), nil
+}
+
+// ListSegmentRankedVideos retrieves a page of top-ranked videos within segments, e.g. a category in a region.
+// Category, language and region match case-insensitively, as they are lowercased when stored.
+func (s *ScoreService) ListSegmentRankedVideos(
+	ctx context.Context, filter entity.RankingSegments, cursor string, limit int,
+) (*entity.RankingPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[constant.SegmentDimension]string{
+		constant.SegmentCategory: util.NormalizeLabel(filter.Category),
+		constant.SegmentCreator:  strings.TrimSpace(filter.CreatorID),
+		constant.SegmentLanguage: util.NormalizeLabel(filter.Language),
+		constant.SegmentRegion:   util.NormalizeLabel(filter.Region),
+	}
+	var segments []string
+	for _, dimension := range []constant.SegmentDimension{
+		constant.SegmentCategory, constant.SegmentCreator, constant.SegmentLanguage, constant.SegmentRegion,
+	} {
+		if values[dimension] == "" {
+			continue
+		}
+		if !slices.Contains(s.opts.SegmentDimensions, dimension) {
+			return nil, fmt.Errorf("%w: %s", ErrSegmentDisabled, dimension)
 		}
-	}()
+		segments = append(segments, entity.Segment(dimension, values[dimension]))
+	}
+	if len(segments) == 0 {
+		return s.ListTopRankedVideos(ctx, cursor, limit)
+	}
 
-	return nil
+	videos, err := s.repo.GetTopSegmentVideos(ctx, segments, after, int64(limit), s.opts.SegmentCacheTTL)
+	if err != nil {
+		log.Printf("Failed to get top ranked videos of segments %v: %v", segments, err)
+		return nil, err
+	}
+	return newRankingPage(videos, limit), nil
 }
 
-// UpdateVideoScoreInDB updates the global score for a video in the database and cache
-func (s *ScoreService) UpdateVideoScoreInDB(ctx context.Context, event *entity.InteractionEvent) error {
-	videoID := event.VideoID
-	newScore := event.InteractionType.GetScore()
+// ListTrendingVideos retrieves the videos whose score is growing fastest compared to their recent baseline
+func (s *ScoreService) ListTrendingVideos(ctx context.Context, limit int) ([]*entity.TrendingVideo, error) {
+	videos, err := s.repo.GetTrendingVideos(ctx, int64(limit))
+	if err != nil {
+		log.Printf("Failed to get trending videos: %v", err)
+		return nil, err
+	}
+	return videos, nil
+}
 
-	currentScore, err := s.repo.GetByVideo(ctx, videoID)
-	if err != nil && err != mongo.ErrNoDocuments {
-		log.Printf("Failed to get current score for video %s: %v", videoID, err)
-		return err
+// startHotRebaser rebases the hot ranking whenever its epoch is older than HotRebaseAfter.
+// Hot scores grow exponentially with the event time, rebasing scales them back down.
+// A zero HotRebaseInterval disables it.
+func (s *ScoreService) startHotRebaser(ctx context.Context) {
+	if s.opts.HotRebaseInterval <= 0 {
+		return
 	}
 
-	if err == mongo.ErrNoDocuments {
-		log.Printf("No current score found for video %s, inserting new score", videoID)
-		if err := s.repo.InsertOne(ctx, videoID, newScore); err != nil {
-			log.Printf("Failed to insert new score for video %s: %v", videoID, err)
-			return err
+	ticker := time.NewTicker(s.opts.HotRebaseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			epoch, err := s.repo.GetHotEpoch(ctx)
+			if err != nil || epoch.IsZero() || time.Since(epoch) < s.opts.HotRebaseAfter {
+				continue
+			}
+			if _, err := s.repo.RebaseHotRanking(ctx, s.opts.HotHalfLife, s.opts.HotMinScore); err != nil {
+				log.Printf("Failed to rebase hot ranking: %v", err)
+			}
 		}
-	} else {
-		log.Printf("Updating score for video %s", videoID)
-		if err := s.repo.IncrementScore(ctx, videoID, newScore); err != nil {
-			log.Printf("Failed to update score for video %s: %v", videoID, err)
-			return err
+	}
+}
+
+// ListPersonalTopRankedVideos retrieves a page of top-ranked videos for a specific user
+func (s *ScoreService) ListPersonalTopRankedVideos(
+	ctx context.Context, userID string, cursor string, limit int,
+) (*entity.RankingPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rehydratePersonalRanking(ctx, userID); err != nil {
+		return nil, err
+	}
+	videos, err := s.repo.GetPersonalTopRankedVideos(ctx, userID, after, int64(limit))
+	if err != nil {
+		log.Printf("Failed to get personalized top ranked videos for user %s: %v", userID, err)
+		return nil, err
+	}
+	return newRankingPage(videos, limit), nil
+}
+
+// ListBlendedRankedVideos retrieves a page of a user's blended ranking, which mixes the user's personal
+// scores with the global and trending rankings so videos the user has not interacted with surface too.
+// Each source's top BlendCandidates videos are normalized to its top score and summed with the blend
+// weights. A user without personal scores gets the global ranking. With excludeWatched, videos the user
+// interacted with are left out.
+func (s *ScoreService) ListBlendedRankedVideos(
+	ctx context.Context, userID string, cursor string, limit int, excludeWatched bool,
+) (*entity.RankingPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rehydratePersonalRanking(ctx, userID); err != nil {
+		return nil, err
+	}
+	candidates := int64(s.opts.BlendCandidates)
+	personal, err := s.repo.GetPersonalTopRankedVideos(ctx, userID, nil, candidates)
+	if err != nil {
+		log.Printf("[ListBlendedRankedVideos] - [GetPersonalTopRankedVideos] - %v", err)
+		return nil, err
+	}
+	if len(personal) == 0 {
+		// Cold start, nothing is known about the user yet
+		return s.ListTopRankedVideos(ctx, cursor, limit)
+	}
+	global, err := s.repo.GetTopRankedVideos(ctx, nil, candidates)
+	if err != nil {
+		log.Printf("[ListBlendedRankedVideos] - [GetTopRankedVideos] - %v", err)
+		return nil, err
+	}
+	trending, err := s.repo.GetTrendingVideos(ctx, candidates)
+	if err != nil {
+		log.Printf("[ListBlendedRankedVideos] - [GetTrendingVideos] - %v", err)
+		return nil, err
+	}
+
+	scores := map[string]float64{}
+	addNormalized(scores, rankedScores(personal), s.opts.BlendPersonalWeight)
+	addNormalized(scores, rankedScores(global), s.opts.BlendGlobalWeight)
+	velocities := make(map[string]float64, len(trending))
+	for _, video := range trending {
+		velocities[video.VideoID] = video.Velocity
+	}
+	addNormalized(scores, velocities, s.opts.BlendTrendingWeight)
+
+	if excludeWatched && len(scores) > 0 {
+		videoIDs := make([]string, 0, len(scores))
+		for videoID := range scores {
+			videoIDs = append(videoIDs, videoID)
+		}
+		watched, err := s.repo.GetPersonalScores(ctx, userID, videoIDs)
+		if err != nil {
+			log.Printf("[ListBlendedRankedVideos] - [GetPersonalScores] - %v", err)
+			return nil, err
+		}
+		for videoID := range watched {
+			delete(scores, videoID)
 		}
-		newScore += currentScore
 	}
+	return newRankingPage(pageOf(scores, after, limit), limit), nil
+}
+
+// rankedScores maps the videos of a ranking to their scores
+func rankedScores(videos []*entity.RankedVideo) map[string]float64 {
+	scores := make(map[string]float64, len(videos))
+	for _, video := range videos {
+		scores[video.VideoID] = video.Score
+	}
+	return scores
+}
 
-	go func() {
-		if err := s.repo.UpdateCachedScore(ctx, videoID, newScore); err != nil {
-			log.Printf("Failed to update cached score for video %s: %v", videoID, err)
+// addNormalized adds each source score divided by the top source score and multiplied by weight
+func addNormalized(scores map[string]float64, source map[string]float64, weight float64) {
+	top := 0.0
+	for _, score := range source {
+		top = max(top, score)
+	}
+	if top <= 0 || weight == 0 {
+		return
+	}
+	for videoID, score := range source {
+		scores[videoID] += weight * score / top
+	}
+}
+
+// pageOf ranks scores in the order of a Redis ranking, by descending score then descending video ID,
+// and returns up to limit videos after the cursor
+func pageOf(scores map[string]float64, after *entity.RankingCursor, limit int) []*entity.RankedVideo {
+	ranked := make([]*entity.RankedVideo, 0, len(scores))
+	for videoID, score := range scores {
+		ranked = append(ranked, &entity.RankedVideo{VideoID: videoID, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
 		}
-	}()
+		return ranked[i].VideoID > ranked[j].VideoID
+	})
 
+	videos := make([]*entity.RankedVideo, 0, limit)
+	for i, video := range ranked {
+		if len(videos) == limit {
+			break
+		}
+		if after != nil && (video.Score > after.Score || video.Score == after.Score && video.VideoID >= after.VideoID) {
+			continue
+		}
+		video.Rank = int64(i) + 1
+		videos = append(videos, video)
+	}
+	return videos
+}
+
+// rehydratePersonalRanking reloads a user's personal ranking from MongoDB if it was evicted or never built
+func (s *ScoreService) rehydratePersonalRanking(ctx context.Context, userID string) error {
+	if _, err := s.repo.RehydratePersonalRanking(ctx, userID, s.opts.PersonalMaxSize, s.opts.PersonalTTL); err != nil {
+		log.Printf("Failed to rehydrate personal ranking of user %s: %v", userID, err)
+		return err
+	}
 	return nil
 }
 
-// ListTopRankedVideos retrieves a list of top-ranked video IDs from the repository
-func (s *ScoreService) ListTopRankedVideos(ctx context.Context, limit int) ([]string, error) {
-	videos, err := s.repo.GetTopRankedVideos(ctx, int64(limit))
+// GetVideoRank retrieves a video's rank, score and percentile in the global ranking with up to neighbors
+// videos on each side. When userID is set, its standing in that user's personal ranking is included.
+func (s *ScoreService) GetVideoRank(ctx context.Context, videoID string, userID string, neighbors int) (*entity.VideoRank, error) {
+	global, err := s.repo.GetVideoStanding(ctx, videoID, int64(neighbors))
 	if err != nil {
-		log.Printf("Failed to get top ranked videos: %v", err)
+		log.Printf("Failed to get global rank of video %s: %v", videoID, err)
 		return nil, err
 	}
-	return videos, nil
+	if global == nil {
+		return nil, ErrVideoNotRanked
+	}
+
+	rank := &entity.VideoRank{VideoID: videoID, Global: global}
+	if userID != "" {
+		if err := s.rehydratePersonalRanking(ctx, userID); err != nil {
+			return nil, err
+		}
+		rank.Personal, err = s.repo.GetPersonalVideoStanding(ctx, userID, videoID, int64(neighbors))
+		if err != nil {
+			log.Printf("Failed to get personal rank of video %s for user %s: %v", videoID, userID, err)
+			return nil, err
+		}
+	}
+	return rank, nil
 }
 
-// ListPersonalTopRankedVideos retrieves a list of top-ranked video IDs for a specific user
-func (s *ScoreService) ListPersonalTopRankedVideos(ctx context.Context, userID string, limit int) ([]string, error) {
-	videos, err := s.repo.GetPersonalTopRankedVideos(ctx, userID, int64(limit))
+// BatchGetScores retrieves the global score and rank of several videos, in the requested order.
+// Scores come from the Redis rankings, videos missing there fall back to MongoDB without a rank.
+func (s *ScoreService) BatchGetScores(ctx context.Context, videoIDs []string, userID string) ([]*entity.VideoScore, error) {
+	cached, err := s.repo.GetCachedScores(ctx, videoIDs)
 	if err != nil {
-		log.Printf("Failed to get personalized top ranked videos for user %s: %v", userID, err)
+		log.Printf("[BatchGetScores] - [GetCachedScores] - %v", err)
 		return nil, err
 	}
-	return videos, nil
+	if misses := missingVideos(videoIDs, func(videoID string) bool { return cached[videoID] != nil }); len(misses) > 0 {
+		stored, err := s.repo.GetByVideos(ctx, misses)
+		if err != nil {
+			log.Printf("[BatchGetScores] - [GetByVideos] - %v", err)
+			return nil, err
+		}
+		for videoID, score := range stored {
+			cached[videoID] = &entity.VideoScore{VideoID: videoID, Score: score}
+		}
+	}
+
+	var personal map[string]float64
+	if userID != "" {
+		personal, err = s.repo.GetCachedPersonalScores(ctx, userID, videoIDs)
+		if err != nil {
+			log.Printf("[BatchGetScores] - [GetCachedPersonalScores] - %v", err)
+			return nil, err
+		}
+		if misses := missingVideos(videoIDs, func(videoID string) bool { _, ok := personal[videoID]; return ok }); len(misses) > 0 {
+			stored, err := s.repo.GetPersonalScores(ctx, userID, misses)
+			if err != nil {
+				log.Printf("[BatchGetScores] - [GetPersonalScores] - %v", err)
+				return nil, err
+			}
+			for videoID, score := range stored {
+				personal[videoID] = score
+			}
+		}
+	}
+
+	scores := make([]*entity.VideoScore, 0, len(videoIDs))
+	for _, videoID := range videoIDs {
+		score := &entity.VideoScore{VideoID: videoID}
+		if found := cached[videoID]; found != nil {
+			score.Score, score.Rank = found.Score, found.Rank
+		}
+		if userID != "" {
+			score.PersonalScore = util.ToPtr(personal[videoID])
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
+
+// missingVideos returns the distinct video IDs for which found is false
+func missingVideos(videoIDs []string, found func(videoID string) bool) []string {
+	seen := make(map[string]bool, len(videoIDs))
+	var misses []string
+	for _, videoID := range videoIDs {
+		if !found(videoID) && !seen[videoID] {
+			seen[videoID] = true
+			misses = append(misses, videoID)
+		}
+	}
+	return misses
+}
+
+// newRankingPage wraps a page of videos, adding the next cursor when the page is full
+func newRankingPage(videos []*entity.RankedVideo, limit int) *entity.RankingPage {
+	page := &entity.RankingPage{Items: videos}
+	if len(videos) == limit && limit > 0 {
+		last := videos[len(videos)-1]
+		page.NextCursor = encodeCursor(&entity.RankingCursor{Score: last.Score, VideoID: last.VideoID})
+	}
+	return page
+}
+
+// encodeCursor turns a cursor into an opaque URL-safe string
+func encodeCursor(cursor *entity.RankingCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor, an empty string means the first page
+func decodeCursor(cursor string) (*entity.RankingCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var after entity.RankingCursor
+	if err := json.Unmarshal(data, &after); err != nil || after.VideoID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &after, nil
 }
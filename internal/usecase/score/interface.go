@@ -2,23 +2,40 @@ package score
 
 import (
 	"context"
+	"go-server/internal/common/constant"
 	"go-server/internal/entity"
+	"time"
 )
 
 type Action interface {
-	GetByVideo(ctx context.Context, videoID string) (float64, error)
-	IncrementScore(ctx context.Context, videoID string, increment float64) error
-	InsertOne(ctx context.Context, videoID string, score float64) error
+	EnsureIndexes(ctx context.Context, ledgerTTL time.Duration) error
+	ApplyScore(ctx context.Context, ledger *entity.ProcessedEvent) (bool, error)
+	GetProcessedEvent(ctx context.Context, eventID string) (*entity.ProcessedEvent, error)
+	InsertTombstone(ctx context.Context, ledger *entity.ProcessedEvent) (bool, error)
 	GetPersonalScore(ctx context.Context, userID string, videoID string) (float64, error)
-	IncrementPersonalScore(ctx context.Context, userID string, videoID string, increment float64) error
-	InsertPersonalScore(ctx context.Context, userID string, videoID string, score float64) error
+	GetByVideos(ctx context.Context, videoIDs []string) (map[string]float64, error)
+	GetPersonalScores(ctx context.Context, userID string, videoIDs []string) (map[string]float64, error)
 }
 
 type Cache interface {
-	UpdateCachedScore(ctx context.Context, videoID string, score float64) error
-	GetTopRankedVideos(ctx context.Context, limit int64) ([]string, error)
-	GetPersonalTopRankedVideos(ctx context.Context, userID string, limit int64) ([]string, error)
-	UpdatePersonalizedRankingCache(ctx context.Context, userID string, videoID string, score float64) error
+	ApplyCachedScore(ctx context.Context, change *entity.ProcessedEvent, personalScore float64, policy *entity.RankingPolicy) (bool, error)
+	RehydratePersonalRanking(ctx context.Context, userID string, maxSize int64, ttl time.Duration) (bool, error)
+	GetHotEpoch(ctx context.Context) (time.Time, error)
+	RebaseHotRanking(ctx context.Context, halfLife time.Duration, minScore float64) (int64, error)
+	GetTopHotVideos(ctx context.Context, after *entity.RankingCursor, limit int64) ([]*entity.RankedVideo, error)
+	GetTopWindowVideos(
+		ctx context.Context, window constant.RankingWindow, after *entity.RankingCursor, limit int64, cacheTTL time.Duration,
+	) ([]*entity.RankedVideo, error)
+	GetTrendingVideos(ctx context.Context, limit int64) ([]*entity.TrendingVideo, error)
+	GetCachedScores(ctx context.Context, videoIDs []string) (map[string]*entity.VideoScore, error)
+	GetCachedPersonalScores(ctx context.Context, userID string, videoIDs []string) (map[string]float64, error)
+	GetTopRankedVideos(ctx context.Context, after *entity.RankingCursor, limit int64) ([]*entity.RankedVideo, error)
+	GetTopSegmentVideos(
+		ctx context.Context, segments []string, after *entity.RankingCursor, limit int64, cacheTTL time.Duration,
+	) ([]*entity.RankedVideo, error)
+	GetPersonalTopRankedVideos(ctx context.Context, userID string, after *entity.RankingCursor, limit int64) ([]*entity.RankedVideo, error)
+	GetVideoStanding(ctx context.Context, videoID string, neighbors int64) (*entity.VideoStanding, error)
+	GetPersonalVideoStanding(ctx context.Context, userID string, videoID string, neighbors int64) (*entity.VideoStanding, error)
 }
 
 type Repository interface {
@@ -26,9 +43,27 @@ type Repository interface {
 	Cache
 }
 
+// Screener holds back suspicious events before they are scored
+type Screener interface {
+	EnsureIndexes(ctx context.Context) error
+	Screen(ctx context.Context, event *entity.InteractionEvent) (bool, error)
+}
+
+// Catalog looks up the metadata of videos, which decides the segment rankings an event goes to
+type Catalog interface {
+	GetVideos(ctx context.Context, videoIDs []string) (map[string]*entity.Video, error)
+}
+
 type UseCase interface {
 	StartEventConsumer(ctx context.Context)
-	UpdateVideoScoreInDB(ctx context.Context, event *entity.InteractionEvent) error
-	ListTopRankedVideos(ctx context.Context, limit int) ([]string, error)
-	ListPersonalTopRankedVideos(ctx context.Context, userID string, limit int) ([]string, error)
+	ApplyEvent(ctx context.Context, event *entity.InteractionEvent) error
+	ListTopRankedVideos(ctx context.Context, cursor string, limit int) (*entity.RankingPage, error)
+	ListHotRankedVideos(ctx context.Context, cursor string, limit int) (*entity.RankingPage, error)
+	ListWindowRankedVideos(ctx context.Context, window constant.RankingWindow, cursor string, limit int) (*entity.RankingPage, error)
+	ListSegmentRankedVideos(ctx context.Context, segments entity.RankingSegments, cursor string, limit int) (*entity.RankingPage, error)
+	ListTrendingVideos(ctx context.Context, limit int) ([]*entity.TrendingVideo, error)
+	ListPersonalTopRankedVideos(ctx context.Context, userID string, cursor string, limit int) (*entity.RankingPage, error)
+	ListBlendedRankedVideos(ctx context.Context, userID string, cursor string, limit int, excludeWatched bool) (*entity.RankingPage, error)
+	GetVideoRank(ctx context.Context, videoID string, userID string, neighbors int) (*entity.VideoRank, error)
+	BatchGetScores(ctx context.Context, videoIDs []string, userID string) ([]*entity.VideoScore, error)
 }
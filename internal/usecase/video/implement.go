@@ -0,0 +1,151 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"go-server/internal/common/util"
+	"go-server/internal/entity"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrInvalidVideo is returned when the metadata of a video is missing its ID or has an unknown status
+	ErrInvalidVideo = errors.New("invalid video")
+	// ErrNotFound is returned when no video in the catalog has the given ID
+	ErrNotFound = errors.New("video not found")
+)
+
+// Service manages the video catalog
+type Service struct {
+	repo Repository
+}
+
+// NewService creates a new Service instance
+func NewService(r Repository) *Service {
+	return &Service{repo: r}
+}
+
+// EnsureIndexes prepares the video collection
+func (s *Service) EnsureIndexes(ctx context.Context) error {
+	return s.repo.EnsureIndexes(ctx)
+}
+
+// UpsertVideo creates or replaces the metadata of a video and reports whether it was created
+func (s *Service) UpsertVideo(ctx context.Context, req *entity.VideoReq) (bool, error) {
+	video, err := newVideo(req)
+	if err != nil {
+		return false, err
+	}
+
+	created, _, err := s.repo.UpsertVideos(ctx, []*entity.Video{video})
+	if err != nil {
+		log.Printf("[UpsertVideo] - [UpsertVideos] - %v", err)
+		return false, err
+	}
+	return created > 0, nil
+}
+
+// UpsertVideos creates or replaces the metadata of many videos at once. Invalid items are rejected
+// without failing the rest, and when a video appears more than once the last item wins.
+func (s *Service) UpsertVideos(ctx context.Context, reqs []*entity.VideoReq) (*entity.BulkVideoResult, error) {
+	result := &entity.BulkVideoResult{}
+	positions := make(map[string]int, len(reqs))
+	videos := make([]*entity.Video, 0, len(reqs))
+	for i, req := range reqs {
+		video, err := newVideo(req)
+		if err != nil {
+			result.Rejected++
+			result.Errors = append(result.Errors, &entity.BulkItemResult{
+				Index: i, Status: entity.BulkItemRejected, Error: err.Error(),
+			})
+			continue
+		}
+		if position, ok := positions[video.VideoID]; ok {
+			videos[position] = video
+			continue
+		}
+		positions[video.VideoID] = len(videos)
+		videos = append(videos, video)
+	}
+
+	if len(videos) > 0 {
+		created, updated, err := s.repo.UpsertVideos(ctx, videos)
+		if err != nil {
+			log.Printf("[UpsertVideos] - [UpsertVideos] - %v", err)
+			return nil, err
+		}
+		result.Created, result.Updated = created, updated
+	}
+
+	log.Printf("[UpsertVideos] - Created %d, updated %d, rejected %d videos", result.Created, result.Updated, result.Rejected)
+	return result, nil
+}
+
+// GetVideo retrieves the metadata of a video
+func (s *Service) GetVideo(ctx context.Context, videoID string) (*entity.Video, error) {
+	video, err := s.repo.GetVideo(ctx, videoID)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[GetVideo] - [GetVideo] - %v", err)
+		return nil, err
+	}
+	return video, nil
+}
+
+// ListVideos retrieves the videos matching filter, most recently updated first
+func (s *Service) ListVideos(ctx context.Context, filter entity.VideoFilter, limit int, offset int) ([]*entity.Video, error) {
+	filter.Category = util.NormalizeLabel(filter.Category)
+	filter.Language = util.NormalizeLabel(filter.Language)
+	videos, err := s.repo.ListVideos(ctx, filter, int64(limit), int64(offset))
+	if err != nil {
+		log.Printf("[ListVideos] - [ListVideos] - %v", err)
+		return nil, err
+	}
+	return videos, nil
+}
+
+// DeleteVideo removes a video from the catalog, its scores and rankings are left untouched
+func (s *Service) DeleteVideo(ctx context.Context, videoID string) error {
+	err := s.repo.DeleteVideo(ctx, videoID)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("[DeleteVideo] - [DeleteVideo] - %v", err)
+		return err
+	}
+
+	log.Printf("[DeleteVideo] - Deleted video %s", videoID)
+	return nil
+}
+
+// newVideo validates the metadata of a video. Category and language are lowercased so rankings
+// group "Music" and "music" together.
+func newVideo(req *entity.VideoReq) (*entity.Video, error) {
+	video := &entity.Video{
+		VideoID:   strings.TrimSpace(req.VideoID),
+		Title:     strings.TrimSpace(req.Title),
+		Category:  util.NormalizeLabel(req.Category),
+		CreatorID: strings.TrimSpace(req.CreatorID),
+		Language:  util.NormalizeLabel(req.Language),
+		Status:    req.Status,
+	}
+	if video.VideoID == "" {
+		return nil, fmt.Errorf("%w: video_id is required", ErrInvalidVideo)
+	}
+	switch video.Status {
+	case "":
+		video.Status = entity.VideoPublished
+	case entity.VideoPublished, entity.VideoUnpublished:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidVideo, video.Status)
+	}
+	return video, nil
+}
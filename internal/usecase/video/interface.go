@@ -0,0 +1,28 @@
+package video
+
+import (
+	"context"
+
+	"go-server/internal/entity"
+)
+
+type Action interface {
+	EnsureIndexes(ctx context.Context) error
+	UpsertVideos(ctx context.Context, videos []*entity.Video) (int64, int64, error)
+	GetVideo(ctx context.Context, videoID string) (*entity.Video, error)
+	ListVideos(ctx context.Context, filter entity.VideoFilter, limit int64, offset int64) ([]*entity.Video, error)
+	DeleteVideo(ctx context.Context, videoID string) error
+}
+
+type Repository interface {
+	Action
+}
+
+type UseCase interface {
+	EnsureIndexes(ctx context.Context) error
+	UpsertVideo(ctx context.Context, req *entity.VideoReq) (bool, error)
+	UpsertVideos(ctx context.Context, reqs []*entity.VideoReq) (*entity.BulkVideoResult, error)
+	GetVideo(ctx context.Context, videoID string) (*entity.Video, error)
+	ListVideos(ctx context.Context, filter entity.VideoFilter, limit int, offset int) ([]*entity.Video, error)
+	DeleteVideo(ctx context.Context, videoID string) error
+}
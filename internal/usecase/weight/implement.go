@@ -0,0 +1,116 @@
+package weight
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+)
+
+var interactionTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Service holds the active interaction weight table and reloads it periodically
+type Service struct {
+	repo           Repository
+	reloadInterval time.Duration
+
+	mu    sync.RWMutex
+	table *entity.WeightTable
+}
+
+// NewService creates a new Service instance with the initial weight table
+func NewService(r Repository, reloadInterval time.Duration) (*Service, error) {
+	s := &Service{
+		repo:           r,
+		reloadInterval: reloadInterval,
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// StartReloader reloads the weight table every reloadInterval until ctx is done.
+// An invalid table is rejected and the previous one stays active.
+func (s *Service) StartReloader(ctx context.Context) {
+	if s.reloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.reload(); err != nil {
+				log.Printf("[StartReloader] - [reload] - keeping previous weights: %v", err)
+			}
+		}
+	}
+}
+
+// Weight returns the weight of an interaction type and whether the type is known
+func (s *Service) Weight(interactionType constant.InteractionType) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	weight, ok := s.table.Weights[interactionType]
+	return weight, ok
+}
+
+// GetWeightTable returns a copy of the active weight table
+func (s *Service) GetWeightTable() *entity.WeightTable {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &entity.WeightTable{
+		Weights:  maps.Clone(s.table.Weights),
+		Source:   s.table.Source,
+		LoadedAt: s.table.LoadedAt,
+	}
+}
+
+// reload loads, validates and activates the weight table if it changed
+func (s *Service) reload() error {
+	table, err := s.repo.Load()
+	if err != nil {
+		return err
+	}
+	if err := validate(table.Weights); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.table != nil && s.table.Source == table.Source && maps.Equal(s.table.Weights, table.Weights) {
+		return nil
+	}
+	table.LoadedAt = time.Now()
+	s.table = table
+	log.Printf("Loaded interaction weights from %s: %v", table.Source, table.Weights)
+	return nil
+}
+
+// validate checks that the table is non-empty, type names are well-formed and weights are finite and non-negative
+func validate(weights map[constant.InteractionType]float64) error {
+	if len(weights) == 0 {
+		return fmt.Errorf("weight table is empty")
+	}
+	for interactionType, weight := range weights {
+		if !interactionTypePattern.MatchString(string(interactionType)) {
+			return fmt.Errorf("invalid interaction type %q", interactionType)
+		}
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("invalid weight %v for interaction type %q", weight, interactionType)
+		}
+	}
+	return nil
+}
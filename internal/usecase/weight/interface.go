@@ -0,0 +1,18 @@
+package weight
+
+import (
+	"context"
+
+	"go-server/internal/common/constant"
+	"go-server/internal/entity"
+)
+
+type Repository interface {
+	Load() (*entity.WeightTable, error)
+}
+
+type UseCase interface {
+	StartReloader(ctx context.Context)
+	Weight(interactionType constant.InteractionType) (float64, bool)
+	GetWeightTable() *entity.WeightTable
+}